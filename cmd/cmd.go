@@ -17,6 +17,7 @@ limitations under the License.
 package cmd
 
 import (
+	"github.com/raskyld/go-tektasker/internal/config"
 	"github.com/spf13/cobra"
 	"log/slog"
 	"os"
@@ -47,10 +48,12 @@ func New(version string) *cobra.Command {
 
 	root.PersistentFlags().BoolVar(&debug, "debug", false, "run in debug mode")
 	root.PersistentFlags().BoolVar(&ctx.DryRun, "dry-run", false, "only output to stdout")
+	root.PersistentFlags().StringVar(&ctx.ConfigFile, "config", "", "path to the configuration file of the project (default \""+config.DefaultFile+"\")")
 
 	root.AddCommand(NewGenerate(&ctx))
 	root.AddCommand(NewMarkers(&ctx))
 	root.AddCommand(NewInit(&ctx))
+	root.AddCommand(NewRun(&ctx))
 	root.AddCommand(NewVersion(&ctx))
 
 	return root
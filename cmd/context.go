@@ -17,19 +17,89 @@ limitations under the License.
 package cmd
 
 import (
+	"errors"
+	"github.com/raskyld/go-tektasker/internal/config"
 	"github.com/spf13/cobra"
+	"io/fs"
 	"log/slog"
 )
 
 type Context struct {
-	Logger   *slog.Logger
-	DryRun   bool
-	Generate *ContextGenerate
+	Logger     *slog.Logger
+	DryRun     bool
+	ConfigFile string
+	Config     *config.Config
+	Generate   *ContextGenerate
 	Version string
 }
 
 type ContextGenerate struct {
-	Input string
+	Input  string
+	Verify bool
+	Watch  bool
+}
+
+// LoadConfig loads the configuration file of the project,
+// the default file is optional while an explicit one must exist
+func (ctx *Context) LoadConfig(cmd *cobra.Command) error {
+	path := ctx.ConfigFile
+	if path == "" {
+		path = config.DefaultFile
+	}
+
+	cfg, err := config.Load(path)
+	if errors.Is(err, fs.ErrNotExist) && !cmd.Flags().Changed("config") {
+		ctx.Config = config.Default()
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	ctx.Logger.Debug("configuration loaded", "path", path)
+	ctx.Config = cfg
+	return nil
+}
+
+// ResolveGenerate loads the configuration and fills the generate settings
+// which were not given as flags from it
+func (ctx *Context) ResolveGenerate(cmd *cobra.Command) error {
+	err := ctx.LoadConfig(cmd)
+	if err != nil {
+		return err
+	}
+
+	ctx.Generate.Input = stringFlagOr(cmd, "input", ctx.Generate.Input, ctx.Config.Input)
+	return nil
+}
+
+// stringFlagOr returns the value of the flag when it was set on the command-line
+// and the value of the configuration otherwise
+func stringFlagOr(cmd *cobra.Command, name, flagValue, configValue string) string {
+	if cmd.Flags().Changed(name) || configValue == "" {
+		return flagValue
+	}
+
+	return configValue
+}
+
+// boolFlagOr is stringFlagOr for boolean flags
+func boolFlagOr(cmd *cobra.Command, name string, flagValue, configValue bool) bool {
+	if cmd.Flags().Changed(name) {
+		return flagValue
+	}
+
+	return configValue
+}
+
+// sliceFlagOr is stringFlagOr for flags holding a list
+func sliceFlagOr(cmd *cobra.Command, name string, flagValue, configValue []string) []string {
+	if cmd.Flags().Changed(name) || len(configValue) == 0 {
+		return flagValue
+	}
+
+	return configValue
 }
 
 func BindGenerate(ctx *Context, cmd *cobra.Command) {
@@ -38,4 +108,8 @@ func BindGenerate(ctx *Context, cmd *cobra.Command) {
 
 	cmd.PersistentFlags().StringVarP(&gen.Input, "input", "i", ".",
 		"The input packages to generate code for")
+	cmd.PersistentFlags().BoolVar(&gen.Verify, "verify", false,
+		"Do not write anything but fail with a diff if the generated files on disk are stale")
+	cmd.PersistentFlags().BoolVar(&gen.Watch, "watch", false,
+		"Keep running and generate again the input packages which change, the new packages of an input ending with /... are picked up")
 }
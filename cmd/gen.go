@@ -17,15 +17,30 @@ limitations under the License.
 package cmd
 
 import (
+	"errors"
+	"github.com/raskyld/go-tektasker/internal/config"
+	"github.com/raskyld/go-tektasker/internal/gendoc"
 	"github.com/raskyld/go-tektasker/internal/gengo"
 	"github.com/raskyld/go-tektasker/internal/genyaml"
+	"github.com/raskyld/go-tektasker/internal/ir"
+	"github.com/raskyld/go-tektasker/internal/verify"
+	"github.com/raskyld/go-tektasker/internal/watch"
 	"github.com/spf13/cobra"
+	"os"
+	"os/signal"
 	"path/filepath"
 	"sigs.k8s.io/controller-tools/pkg/genall"
 	"strconv"
+	"strings"
 	"time"
 )
 
+// ErrGeneration is returned when at least one generator reported an error
+var ErrGeneration = errors.New("generation failed, see the errors above")
+
+// ErrStale is returned in verify mode when the generated files on disk are not up-to-date
+var ErrStale = errors.New("generated files are stale, run the generation again")
+
 func NewGenerate(ctx *Context) *cobra.Command {
 	generate := &cobra.Command{
 		Use:     "generate",
@@ -37,6 +52,8 @@ func NewGenerate(ctx *Context) *cobra.Command {
 
 	generate.AddCommand(NewGenerateManifest(ctx))
 	generate.AddCommand(NewGenerateGo(ctx))
+	generate.AddCommand(NewGenerateCatalog(ctx))
+	generate.AddCommand(NewGenerateDocs(ctx))
 
 	return generate
 }
@@ -48,19 +65,35 @@ func NewGenerateGo(ctx *Context) *cobra.Command {
 	genFuncGo := &cobra.Command{
 		Use:   "go [internalPkgPath] [internalPkgName]",
 		Short: "Generate the Go code to integrate with Tekton",
-		Long: `This command when run without args is equivalent to
+		Long: `This command when run without args nor configuration file is equivalent to
 
 tektasker gen go internal/ tekton
 
 Which means, the helper functions are gonna be written under internal/tekton
-directory with a package name of "tekton".
+directory with a package name of "tekton". The helpers to unit test your
+tasks are written in its "tektontest" sub-package.
+
+The args default to go.internalPkgPath and go.internalPkgName of tektasker.yaml.
 
 The code generated for your main package will be written in zz_generated.tektasker.go
+
+With --watch, the code is generated again every time the package or the header file
+changes, the errors are reported without exiting.
 `,
 		Args: cobra.MaximumNArgs(2),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			outputInternal := "internal/"
-			outputPkgName := "tekton"
+			// Errors are reported with their position, the usage would only hide them
+			cmd.SilenceUsage = true
+
+			err := ctx.ResolveGenerate(cmd)
+			if err != nil {
+				return err
+			}
+
+			headerFile = stringFlagOr(cmd, "headerfile", headerFile, ctx.Config.HeaderFile)
+
+			outputInternal := ctx.Config.Go.InternalPkgPath
+			outputPkgName := ctx.Config.Go.InternalPkgName
 
 			if len(args) > 0 {
 				outputInternal = args[0]
@@ -70,110 +103,475 @@ The code generated for your main package will be written in zz_generated.tektask
 				outputPkgName = args[1]
 			}
 
-			var genFunc, genInternal genall.Generator
+			outputDir := filepath.Join(outputInternal, outputPkgName)
 
-			genFuncPtr, err := gengo.NewGoFunc(ctx.Logger, headerFile, year)
-			if err != nil {
-				return err
-			}
+			generate := func(changed []string) ([]string, error) {
+				var genFunc, genInternal genall.Generator
 
-			genFunc = genFuncPtr
+				genFuncPtr, err := gengo.NewGoFunc(ctx.Logger, ir.NewBuilder(ctx.Logger), headerFile, year)
+				if err != nil {
+					return nil, err
+				}
 
-			genInternalPtr, err := gengo.NewGoInternal(ctx.Logger, outputPkgName, headerFile, year)
-			if err != nil {
-				return err
-			}
+				genFunc = genFuncPtr
 
-			genInternal = genInternalPtr
+				genInternalPtr, err := gengo.NewGoInternal(ctx.Logger, outputPkgName, outputDir, headerFile, year)
+				if err != nil {
+					return nil, err
+				}
 
-			gens := genall.Generators{&genFunc, &genInternal}
+				genInternal = genInternalPtr
 
-			runtime, err := gens.ForRoots(ctx.Generate.Input)
-			if err != nil {
-				return err
-			}
+				gens := genall.Generators{&genFunc, &genInternal}
 
-			if ctx.DryRun {
-				runtime.OutputRules = genall.OutputRules{
-					Default:     genall.OutputToStdout,
-					ByGenerator: nil,
+				runtime, err := gens.ForRoots(rootsOf(ctx, changed)...)
+				if err != nil {
+					return nil, err
 				}
-			} else {
-				runtime.OutputRules = genall.OutputRules{
-					Default: genall.OutputArtifacts{},
-					ByGenerator: map[*genall.Generator]genall.OutputRule{
-						&genInternal: genall.OutputToDirectory(filepath.Join(outputInternal, outputPkgName)),
-					},
+
+				recorder := verify.NewRecorder()
+				if ctx.Generate.Verify && headerFile != "" && !cmd.Flags().Changed("year") {
+					// the files generated a previous year are not stale because of the default year
+					header, err := os.ReadFile(headerFile)
+					if err != nil {
+						return nil, err
+					}
+
+					recorder.AllowYears(string(header), year)
+				}
+
+				if ctx.Generate.Verify {
+					runtime.OutputRules = genall.OutputRules{
+						Default: recorder.ToPackage(),
+						ByGenerator: map[*genall.Generator]genall.OutputRule{
+							&genInternal: recorder.ToDirectory(outputDir),
+						},
+					}
+				} else if ctx.DryRun {
+					runtime.OutputRules = genall.OutputRules{
+						Default:     genall.OutputToStdout,
+						ByGenerator: nil,
+					}
+				} else {
+					runtime.OutputRules = genall.OutputRules{
+						Default: genall.OutputArtifacts{},
+						ByGenerator: map[*genall.Generator]genall.OutputRule{
+							&genInternal: genall.OutputToDirectory(outputDir),
+						},
+					}
 				}
+
+				sources := sourcesOf(ctx, runtime, headerFile)
+				if runtime.Run() {
+					return sources, ErrGeneration
+				}
+
+				return sources, verifyRecorded(ctx, cmd, recorder)
 			}
 
-			runtime.Run()
-			return nil
+			return runGeneration(ctx, cmd, generate)
 		},
 	}
 
 	genFuncGo.Flags().StringVarP(&headerFile, "headerfile", "b", "", "Path to a boilerplate header file to put at the top of any generated go code. TIPS: ' YEAR' will be replaced with the current year!")
-	genFuncGo.Flags().StringVar(&year, "year", strconv.Itoa(time.Now().Year()), "Which year should be written in the headerfile")
+	genFuncGo.Flags().StringVar(&year, "year", strconv.Itoa(time.Now().Year()), "Which year should be written in the headerfile, when it is not set --verify accepts the files generated any year")
 	return genFuncGo
 }
 
 func NewGenerateManifest(ctx *Context) *cobra.Command {
 	var stepCommand string
+	var image imageFlags
+	var apiVersions []string
+	var stepActionVersion string
+	var samplePipeline bool
 
 	genYaml := &cobra.Command{
-		Use:   "manifest output-dir",
+		Use:   "manifest [output-dir]",
 		Short: "Generate your YAML manifests and write them in the given output-dir",
+		Long: `Every Task is written in a kustomize base along with a sample TaskRun filled with the
+default values of its params, the samples are not resources of the kustomization.
+The StepActions and the Pipelines are written in the same kustomize base.
+
+The output-dir defaults to manifest.output of tektasker.yaml.
+`,
 		Example: `
 # Generate Task manifest for the current working directory package
 tektasker gen manifest ./manifests/
 
+# Generate Task manifest in the manifest.output directory of tektasker.yaml
+tektasker gen manifest
+
 # Generate Task manifest for a specific package
 tektasker gen -i ./pkg/helloworld manifest ./manifests/
 
 # Generate Task for every package in pkg/
 tektasker gen -i ./pkg/... manifest ./manifests/
+
+# Generate both v1 (in base/) and v1beta1 (in base-v1beta1/) Task manifests
+tektasker gen manifest --api-version v1,v1beta1 ./manifests/
+
+# Use the image built by your CI instead of building it with ko
+tektasker gen manifest --image-strategy template --image 'registry.example.com/{{.TaskName}}:{{.Version}}' ./manifests/
+
+# Also write a sample Pipeline next to the sample TaskRun of the Task
+tektasker gen manifest --sample-pipeline ./manifests/
+
+# Fail with a diff if the manifests in ./manifests/ are stale, e.g. in your CI
+tektasker gen --verify manifest ./manifests/
+
+# Generate the manifests again every time the package changes
+tektasker gen --watch manifest ./manifests/
 `,
-		Args: ResolveManifestArgs(ctx),
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			var gen genall.Generator = genyaml.TaskYamlGenerator{
-				Logger:      ctx.Logger,
-				StepCommand: stepCommand,
+			err := ctx.ResolveGenerate(cmd)
+			if err != nil {
+				return err
+			}
+
+			stepCommand = stringFlagOr(cmd, "command", stepCommand, ctx.Config.Manifest.Command)
+			apiVersions = sliceFlagOr(cmd, "api-version", apiVersions, ctx.Config.Manifest.APIVersions)
+			stepActionVersion = stringFlagOr(cmd, "stepaction-version", stepActionVersion, ctx.Config.Manifest.StepActionVersion)
+			samplePipeline = boolFlagOr(cmd, "sample-pipeline", samplePipeline, ctx.Config.Manifest.SamplePipeline)
+
+			outputDir := ctx.Config.Manifest.Output
+			if len(args) > 0 {
+				outputDir = args[0]
+			}
+
+			if outputDir == "" && (!ctx.DryRun || ctx.Generate.Verify) {
+				return errors.New("output-dir must be given as argument or as manifest.output in " + config.DefaultFile)
 			}
-			gens := genall.Generators{&gen}
 
-			runtime, err := gens.ForRoots(ctx.Generate.Input)
+			// Errors are reported with their position, the usage would only hide them
+			cmd.SilenceUsage = true
+
+			versions, err := genyaml.LookupAPIVersions(apiVersions)
 			if err != nil {
 				return err
 			}
 
-			var outRule genall.OutputRule
-			if ctx.DryRun {
-				outRule = genall.OutputToStdout
-			} else {
-				outRule = genall.OutputToDirectory(args[0])
+			_, err = genyaml.LookupStepActionVersion(stepActionVersion)
+			if err != nil {
+				return err
 			}
 
-			runtime.OutputRules = genall.OutputRules{
-				Default: outRule,
+			imageStrategy, err := image.resolve(ctx, cmd, stepCommand)
+			if err != nil {
+				return err
 			}
 
-			runtime.Run()
-			return nil
+			generate := func(changed []string) ([]string, error) {
+				gen := genyaml.TaskYamlGenerator{
+					Logger:      ctx.Logger,
+					Builder:     ir.NewBuilder(ctx.Logger),
+					APIVersions: versions,
+					StepCommand: stepCommand,
+					Image:       imageStrategy,
+
+					SamplePipeline:    samplePipeline,
+					StepActionVersion: stepActionVersion,
+				}
+
+				// the kustomization lists every Task so the manifests are always all generated again
+				return generateToDirectory(ctx, cmd, gen, []string{ctx.Generate.Input}, outputDir, genyaml.ManifestFiles...)
+			}
+
+			return runGeneration(ctx, cmd, generate)
 		},
 	}
 
-	genYaml.Flags().StringVar(&stepCommand, "command", "ko-app/{{.KoAppName}}", "What is the entrypoint of the container image of the task")
+	genYaml.Flags().StringVar(&stepCommand, "command", "ko-app/{{.KoAppName}}", "What is the entrypoint of the container image of the task when it is built with ko")
+	image.bind(genYaml)
+	genYaml.Flags().StringVar(&stepActionVersion, "stepaction-version", genyaml.DefaultStepActionVersion, "Tekton API version of the StepAction manifests (v1alpha1, v1beta1), they are written in every kustomize base")
+	genYaml.Flags().BoolVar(&samplePipeline, "sample-pipeline", false, "Also write a sample Pipeline passing the results of the task to a downstream task, next to its sample TaskRun")
+	genYaml.Flags().StringSliceVar(&apiVersions, "api-version", []string{genyaml.V1.Version}, "Tekton API versions to emit (v1, v1beta1), each one is written to its own kustomize base: base/ for v1 and base-v1beta1/ for v1beta1")
 
 	return genYaml
 }
 
-// ResolveManifestArgs resolves how many argument is accepted by the command at runtime
-func ResolveManifestArgs(ctx *Context) cobra.PositionalArgs {
-	return func(cmd *cobra.Command, args []string) error {
-		if ctx.DryRun {
-			return cobra.MaximumNArgs(1)(cmd, args)
-		} else {
-			return cobra.ExactArgs(1)(cmd, args)
+func NewGenerateCatalog(ctx *Context) *cobra.Command {
+	var stepCommand string
+	var image imageFlags
+	var apiVersion string
+
+	genCatalog := &cobra.Command{
+		Use:   "catalog [output-dir]",
+		Short: "Generate your Tasks in the layout of the Tekton Catalog and write them in the given output-dir",
+		Long: `Every Task is written in task/<name>/<version>/<name>.yaml with a README.md next to it,
+the catalog annotations are taken from the task marker.
+
+The output-dir defaults to catalog.output of tektasker.yaml.
+`,
+		Example: `
+# Add the Task of the current working directory package to a catalog
+tektasker gen catalog ./catalog/
+
+# Add the Task of every package in pkg/ to a catalog
+tektasker gen -i ./pkg/... catalog ./catalog/
+`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := ctx.ResolveGenerate(cmd)
+			if err != nil {
+				return err
+			}
+
+			stepCommand = stringFlagOr(cmd, "command", stepCommand, ctx.Config.Manifest.Command)
+			apiVersion = stringFlagOr(cmd, "api-version", apiVersion, ctx.Config.Catalog.APIVersion)
+
+			outputDir := ctx.Config.Catalog.Output
+			if len(args) > 0 {
+				outputDir = args[0]
+			}
+
+			if outputDir == "" && (!ctx.DryRun || ctx.Generate.Verify) {
+				return errors.New("output-dir must be given as argument or as catalog.output in " + config.DefaultFile)
+			}
+
+			// Errors are reported with their position, the usage would only hide them
+			cmd.SilenceUsage = true
+
+			version, err := genyaml.LookupAPIVersion(apiVersion)
+			if err != nil {
+				return err
+			}
+
+			imageStrategy, err := image.resolve(ctx, cmd, stepCommand)
+			if err != nil {
+				return err
+			}
+
+			generate := func(changed []string) ([]string, error) {
+				gen := genyaml.TaskCatalogGenerator{
+					TaskYamlGenerator: genyaml.TaskYamlGenerator{
+						Logger:      ctx.Logger,
+						Builder:     ir.NewBuilder(ctx.Logger),
+						APIVersions: []genyaml.APIVersion{version},
+						StepCommand: stepCommand,
+						Image:       imageStrategy,
+					},
+				}
+
+				return generateToDirectory(ctx, cmd, gen, rootsOf(ctx, changed), outputDir)
+			}
+
+			return runGeneration(ctx, cmd, generate)
+		},
+	}
+
+	genCatalog.Flags().StringVar(&stepCommand, "command", "ko-app/{{.KoAppName}}", "What is the entrypoint of the container image of the task when it is built with ko")
+	image.bind(genCatalog)
+	genCatalog.Flags().StringVar(&apiVersion, "api-version", genyaml.V1.Version, "Tekton API version of the catalog (v1, v1beta1)")
+
+	return genCatalog
+}
+
+func NewGenerateDocs(ctx *Context) *cobra.Command {
+	var apiVersion string
+
+	genDocs := &cobra.Command{
+		Use:   "docs [output-dir]",
+		Short: "Generate the Markdown reference of your Tasks and write them in the given output-dir",
+		Long: `The README of every Task is written in <name>/README.md, it documents the params,
+results and workspaces from their GoDoc and shows how to use the Task in a TaskRun and in a Pipeline.
+
+The output-dir defaults to docs.output of tektasker.yaml.
+`,
+		Example: `
+# Document the Task of the current working directory package
+tektasker gen docs ./docs/
+
+# Document the Task of every package in pkg/
+tektasker gen -i ./pkg/... docs ./docs/
+`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := ctx.ResolveGenerate(cmd)
+			if err != nil {
+				return err
+			}
+
+			apiVersion = stringFlagOr(cmd, "api-version", apiVersion, ctx.Config.Docs.APIVersion)
+
+			outputDir := ctx.Config.Docs.Output
+			if len(args) > 0 {
+				outputDir = args[0]
+			}
+
+			if outputDir == "" && (!ctx.DryRun || ctx.Generate.Verify) {
+				return errors.New("output-dir must be given as argument or as docs.output in " + config.DefaultFile)
+			}
+
+			// Errors are reported with their position, the usage would only hide them
+			cmd.SilenceUsage = true
+
+			version, err := genyaml.LookupAPIVersion(apiVersion)
+			if err != nil {
+				return err
+			}
+
+			// the manifests are installed from the kustomize base of the API version
+			var manifestDir string
+			if ctx.Config.Manifest.Output != "" {
+				manifestDir = filepath.Join(ctx.Config.Manifest.Output, version.BaseDir)
+			}
+
+			generate := func(changed []string) ([]string, error) {
+				gen := gendoc.Generator{
+					Logger:      ctx.Logger,
+					Builder:     ir.NewBuilder(ctx.Logger),
+					APIVersion:  version.GroupVersion(),
+					ManifestDir: manifestDir,
+				}
+
+				return generateToDirectory(ctx, cmd, gen, rootsOf(ctx, changed), outputDir)
+			}
+
+			return runGeneration(ctx, cmd, generate)
+		},
+	}
+
+	genDocs.Flags().StringVar(&apiVersion, "api-version", genyaml.V1.Version, "Tekton API version of the usage examples (v1, v1beta1)")
+
+	return genDocs
+}
+
+// imageFlags are the flags choosing the image strategy of the Tasks
+type imageFlags struct {
+	strategy  string
+	reference string
+}
+
+func (f *imageFlags) bind(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.strategy, "image-strategy", ir.ImageStrategyKo, "How the image of the steps is built: ko, fixed (an image reference pinned by digest) or template (an image reference computed from a template), the image marker of a task overrides it")
+	cmd.Flags().StringVar(&f.reference, "image", "", "Image reference of the fixed image strategy or its template for the template image strategy, e.g. registry.example.com/{{.TaskName}}:{{.Version}}")
+}
+
+// resolve the image strategy from the flags and the configuration
+func (f *imageFlags) resolve(ctx *Context, cmd *cobra.Command, stepCommand string) (genyaml.ImageStrategy, error) {
+	image := &ir.Image{
+		Strategy:  stringFlagOr(cmd, "image-strategy", f.strategy, ctx.Config.Manifest.Image.Strategy),
+		Reference: stringFlagOr(cmd, "image", f.reference, ctx.Config.Manifest.Image.Reference),
+		Command:   ctx.Config.Manifest.Image.Command,
+	}
+
+	// the command of the configuration belongs to its strategy
+	if cmd.Flags().Changed("image-strategy") && image.Strategy != ctx.Config.Manifest.Image.Strategy {
+		image.Command = nil
+	}
+
+	return genyaml.NewImageStrategy(image, stepCommand, nil)
+}
+
+// runGeneration runs the generation once or, in watch mode, every time its sources change
+func runGeneration(ctx *Context, cmd *cobra.Command, generate watch.Generate) error {
+	if !ctx.Generate.Watch {
+		_, err := generate(nil)
+		return err
+	}
+
+	if ctx.Generate.Verify {
+		return errors.New("--watch and --verify can not be used together")
+	}
+
+	signalCtx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+	defer stop()
+
+	return watch.New(ctx.Logger).Run(signalCtx, generate)
+}
+
+// generateToDirectory runs the generator on the roots and writes its files in outputDir,
+// they are only compared to the files on disk in verify mode and printed in dry-run mode.
+// generated are the patterns of the names of the files the generator owns in outputDir,
+// the ones which are no longer generated are stale in verify mode
+func generateToDirectory(ctx *Context, cmd *cobra.Command, gen genall.Generator, roots []string, outputDir string, generated ...string) ([]string, error) {
+	gens := genall.Generators{&gen}
+
+	runtime, err := gens.ForRoots(roots...)
+	if err != nil {
+		return nil, err
+	}
+
+	recorder := verify.NewRecorder()
+
+	var outRule genall.OutputRule
+	if ctx.Generate.Verify {
+		outRule = recorder.ToDirectory(outputDir, generated...)
+	} else if ctx.DryRun {
+		outRule = genall.OutputToStdout
+	} else {
+		outRule = genall.OutputToDirectory(outputDir)
+	}
+
+	runtime.OutputRules = genall.OutputRules{
+		Default: outRule,
+	}
+
+	sources := sourcesOf(ctx, runtime)
+	if runtime.Run() {
+		return sources, ErrGeneration
+	}
+
+	return sources, verifyRecorded(ctx, cmd, recorder)
+}
+
+// rootsOf returns the packages to generate: the input packages for a full generation,
+// the directories of the packages which changed otherwise
+func rootsOf(ctx *Context, changed []string) []string {
+	if changed == nil {
+		return []string{ctx.Generate.Input}
+	}
+
+	return changed
+}
+
+// sourcesOf lists the directories of the root packages of the runtime and the extra files
+// a generation is made from, along with the input pattern so its new packages are picked up
+func sourcesOf(ctx *Context, runtime *genall.Runtime, extraFiles ...string) []string {
+	var sources []string
+	seen := make(map[string]struct{})
+
+	if strings.HasSuffix(ctx.Generate.Input, "/...") {
+		sources = append(sources, ctx.Generate.Input)
+	}
+
+	for _, root := range runtime.Roots {
+		if len(root.GoFiles) == 0 {
+			continue
+		}
+
+		dir := filepath.Dir(root.GoFiles[0])
+		if _, ok := seen[dir]; !ok {
+			seen[dir] = struct{}{}
+			sources = append(sources, dir)
+		}
+	}
+
+	for _, file := range extraFiles {
+		if file != "" {
+			sources = append(sources, file)
 		}
 	}
+
+	return sources
+}
+
+// verifyRecorded prints the diff of the recorded files against the disk in verify mode
+func verifyRecorded(ctx *Context, cmd *cobra.Command, recorder *verify.Recorder) error {
+	if !ctx.Generate.Verify {
+		return nil
+	}
+
+	stale, err := recorder.Diff(cmd.OutOrStdout())
+	if err != nil {
+		return err
+	}
+
+	if stale {
+		return ErrStale
+	}
+
+	ctx.Logger.Info("generated files are up-to-date")
+	return nil
 }
@@ -50,7 +50,7 @@ func NewInit(ctx *Context) *cobra.Command {
 				return err
 			}
 
-			ctx.Logger.Info("project init has been successful, please check the .env file for further configuration")
+			ctx.Logger.Info("project init has been successful, please check the .env and tektasker.yaml files for further configuration")
 
 			return nil
 		},
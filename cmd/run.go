@@ -0,0 +1,109 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/raskyld/go-tektasker/internal/ir"
+	"github.com/raskyld/go-tektasker/internal/run"
+	"github.com/spf13/cobra"
+	"strings"
+)
+
+func NewRun(ctx *Context) *cobra.Command {
+	var params []string
+	var workspaces []string
+
+	runCmd := &cobra.Command{
+		Use:   "run [package]",
+		Short: "Build and run a Task locally with the environment Tekton would give to its steps",
+		Example: `
+# Run the Task of the current working directory package with the default params
+tektasker run
+
+# Run a specific package and override some params
+tektasker run ./pkg/helloworld -p who=tekton -p files='["a.txt","b.txt"]'
+
+# Use an existing directory as the source workspace
+tektasker run -w source=./testdata
+`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Errors are reported with their position, the usage would only hide them
+			cmd.SilenceUsage = true
+
+			input := "."
+			if len(args) > 0 {
+				input = args[0]
+			}
+
+			opts := run.Options{}
+
+			var err error
+			opts.Params, err = parseKeyValues("param", params)
+			if err != nil {
+				return err
+			}
+
+			opts.Workspaces, err = parseKeyValues("workspace", workspaces)
+			if err != nil {
+				return err
+			}
+
+			runner := run.New(ctx.Logger, ir.NewBuilder(ctx.Logger))
+			runner.Stdout, runner.Stderr = cmd.ErrOrStderr(), cmd.ErrOrStderr()
+
+			task, err := runner.Load(input)
+			if err != nil {
+				return err
+			}
+
+			results, err := runner.Run(cmd.Context(), task, opts)
+			if err != nil {
+				return err
+			}
+
+			for _, result := range task.Results {
+				if value, ok := results[result.Name]; ok {
+					fmt.Fprintf(cmd.OutOrStdout(), "%s: %s\n", result.Name, value)
+				}
+			}
+
+			return nil
+		},
+	}
+
+	runCmd.Flags().StringArrayVarP(&params, "param", "p", nil, "Set a param as name=value, arrays and objects are given as JSON")
+	runCmd.Flags().StringArrayVarP(&workspaces, "workspace", "w", nil, "Bind a workspace to a directory as name=path, a temporary directory is used otherwise")
+
+	return runCmd
+}
+
+// parseKeyValues parses a list of key=value flags
+func parseKeyValues(kind string, flags []string) (map[string]string, error) {
+	values := make(map[string]string, len(flags))
+	for _, flag := range flags {
+		key, value, found := strings.Cut(flag, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("%s %q must be given as name=value", kind, flag)
+		}
+
+		values[key] = value
+	}
+
+	return values, nil
+}
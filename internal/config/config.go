@@ -0,0 +1,176 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package config holds the configuration of a project using tektasker,
+// it is read from a tektasker.yaml file at the root of the project.
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultFile is the configuration file looked up in the working directory
+const DefaultFile = "tektasker.yaml"
+
+// Config is the configuration of a project, the flags of the command-line
+// take precedence over it
+type Config struct {
+	// Input is the packages to generate code for
+	Input string `json:"input,omitempty"`
+
+	// HeaderFile is the boilerplate header put at the top of the generated Go files
+	HeaderFile string `json:"headerFile,omitempty"`
+
+	Go       Go       `json:"go,omitempty"`
+	Manifest Manifest `json:"manifest,omitempty"`
+	Catalog  Catalog  `json:"catalog,omitempty"`
+	Docs     Docs     `json:"docs,omitempty"`
+}
+
+// Go configures the generation of the Go code
+type Go struct {
+	// InternalPkgPath is the directory the helper package is written in
+	InternalPkgPath string `json:"internalPkgPath,omitempty"`
+
+	// InternalPkgName is the name of the helper package
+	InternalPkgName string `json:"internalPkgName,omitempty"`
+}
+
+// Manifest configures the generation of the Task manifests
+type Manifest struct {
+	// Output is the directory the kustomize bases are written in
+	Output string `json:"output,omitempty"`
+
+	// Command is the template of the entrypoint of the container image of the steps built with ko
+	Command string `json:"command,omitempty"`
+
+	// Image is how the container image of the steps is built, the image marker of a Task overrides it
+	Image Image `json:"image,omitempty"`
+
+	// APIVersions are the Tekton API versions to emit
+	APIVersions []string `json:"apiVersions,omitempty"`
+
+	// StepActionVersion is the Tekton API version of the StepAction manifests
+	StepActionVersion string `json:"stepActionVersion,omitempty"`
+
+	// SamplePipeline also writes a sample Pipeline next to the sample TaskRun of every Task
+	SamplePipeline bool `json:"samplePipeline,omitempty"`
+}
+
+// Image configures the image strategy of the Tasks
+type Image struct {
+	// Strategy is one of ko, fixed or template
+	Strategy string `json:"strategy,omitempty"`
+
+	// Reference is the image reference pinned by digest of the fixed strategy
+	// or the template of the image reference of the template strategy
+	Reference string `json:"reference,omitempty"`
+
+	// Command is the entrypoint of the image, the fixed and template strategies
+	// use the entrypoint of the image without it
+	Command []string `json:"command,omitempty"`
+}
+
+// Catalog configures the generation of the Tasks in the layout of the Tekton Catalog,
+// they share the command of the manifests
+type Catalog struct {
+	// Output is the root of the catalog, the Tasks are written in its task/ directory
+	Output string `json:"output,omitempty"`
+
+	// APIVersion is the Tekton API version of the catalog
+	APIVersion string `json:"apiVersion,omitempty"`
+}
+
+// Docs configures the generation of the README of the Tasks
+type Docs struct {
+	// Output is the directory the README of every Task is written in, under <name>/README.md
+	Output string `json:"output,omitempty"`
+
+	// APIVersion is the Tekton API version of the usage examples
+	APIVersion string `json:"apiVersion,omitempty"`
+}
+
+// Default is the configuration used when there is no configuration file
+func Default() *Config {
+	return &Config{
+		Input: ".",
+		Go: Go{
+			InternalPkgPath: "internal/",
+			InternalPkgName: "tekton",
+		},
+		Manifest: Manifest{
+			Command:           "ko-app/{{.KoAppName}}",
+			APIVersions:       []string{"v1"},
+			StepActionVersion: "v1beta1",
+			Image: Image{
+				Strategy: "ko",
+			},
+		},
+		Catalog: Catalog{
+			APIVersion: "v1",
+		},
+		Docs: Docs{
+			APIVersion: "v1",
+		},
+	}
+}
+
+// Load the configuration file, the settings it does not set keep their default.
+// The relative paths are relative to the directory of the configuration file.
+func Load(path string) (*Config, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := Default()
+	err = yaml.UnmarshalStrict(buf, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid configuration file %s: %w", path, err)
+	}
+
+	cfg.resolvePaths(filepath.Dir(path))
+	return cfg, nil
+}
+
+// resolvePaths makes the relative paths of the configuration relative to dir
+func (c *Config) resolvePaths(dir string) {
+	if dir == "." {
+		return
+	}
+
+	for _, path := range []*string{&c.HeaderFile, &c.Go.InternalPkgPath, &c.Manifest.Output, &c.Catalog.Output, &c.Docs.Output} {
+		if *path != "" && !filepath.IsAbs(*path) {
+			*path = filepath.Join(dir, *path)
+		}
+	}
+
+	// only the relative patterns are paths, the others are import paths
+	if c.Input == "." || strings.HasPrefix(c.Input, "./") || strings.HasPrefix(c.Input, "../") {
+		input := filepath.ToSlash(filepath.Join(dir, c.Input))
+		if !filepath.IsAbs(input) && input != "." && input != ".." && !strings.HasPrefix(input, "../") {
+			// a pattern without a leading dot is an import path
+			input = "./" + input
+		}
+
+		c.Input = input
+	}
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResolvePaths(t *testing.T) {
+	tests := []struct {
+		name   string
+		dir    string
+		args   Config
+		result Config
+	}{
+		{
+			"Working directory",
+			".",
+			Config{Input: "./pkg/...", HeaderFile: "hack/header.txt"},
+			Config{Input: "./pkg/...", HeaderFile: "hack/header.txt"},
+		},
+		{
+			"Sub-directory",
+			"project",
+			Config{
+				Input:      "./...",
+				HeaderFile: "hack/header.txt",
+				Go:         Go{InternalPkgPath: "internal/", InternalPkgName: "tekton"},
+				Manifest:   Manifest{Output: "manifests", Command: "ko-app/{{.KoAppName}}"},
+				Catalog:    Catalog{Output: "../catalog"},
+				Docs:       Docs{Output: "/abs/docs"},
+			},
+			Config{
+				Input:      "./project/...",
+				HeaderFile: "project/hack/header.txt",
+				Go:         Go{InternalPkgPath: "project/internal", InternalPkgName: "tekton"},
+				Manifest:   Manifest{Output: "project/manifests", Command: "ko-app/{{.KoAppName}}"},
+				Catalog:    Catalog{Output: "catalog"},
+				Docs:       Docs{Output: "/abs/docs"},
+			},
+		},
+		{
+			"Parent directory",
+			"..",
+			Config{Input: "."},
+			Config{Input: ".."},
+		},
+		{
+			"Absolute directory",
+			"/project",
+			Config{Input: "./pkg/..."},
+			Config{Input: "/project/pkg/..."},
+		},
+		{
+			"Import path",
+			"project",
+			Config{Input: "example.com/tasks/..."},
+			Config{Input: "example.com/tasks/..."},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := test.args
+			result.resolvePaths(test.dir)
+
+			if !reflect.DeepEqual(result, test.result) {
+				t.Errorf("unwanted diff, got\n---\n%+v\n---\nwanted\n---\n%+v", result, test.result)
+			}
+		})
+	}
+}
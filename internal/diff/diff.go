@@ -0,0 +1,185 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package diff computes line-based unified diffs.
+package diff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Context is the number of unchanged lines shown around the changes
+const Context = 3
+
+type opKind byte
+
+const (
+	opEqual  opKind = ' '
+	opDelete opKind = '-'
+	opInsert opKind = '+'
+)
+
+type op struct {
+	kind opKind
+	line string
+}
+
+// Unified returns the unified diff turning oldText into newText,
+// it is empty when both texts are equal
+func Unified(oldName, newName, oldText, newText string) string {
+	if oldText == newText {
+		return ""
+	}
+
+	ops := edits(splitLines(oldText), splitLines(newText))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+
+	// oldLine and newLine are the number of lines of each text before ops[i]
+	oldLine, newLine := 0, 0
+	for i := 0; i < len(ops); {
+		if ops[i].kind == opEqual {
+			oldLine++
+			newLine++
+			i++
+			continue
+		}
+
+		// the hunk starts with the context before the first change
+		start := i
+		for start > 0 && i-start < Context && ops[start-1].kind == opEqual {
+			start--
+		}
+
+		// and ends once there are more than 2*Context unchanged lines
+		end := i
+		for end < len(ops) {
+			if ops[end].kind != opEqual {
+				end++
+				continue
+			}
+
+			equals := 0
+			for end+equals < len(ops) && ops[end+equals].kind == opEqual {
+				equals++
+			}
+
+			if end+equals == len(ops) || equals > 2*Context {
+				end += min(equals, Context)
+				break
+			}
+
+			end += equals
+		}
+
+		hunkOld, hunkNew := oldLine-(i-start), newLine-(i-start)
+		oldCount, newCount := 0, 0
+		for _, o := range ops[start:end] {
+			if o.kind != opInsert {
+				oldCount++
+			}
+
+			if o.kind != opDelete {
+				newCount++
+			}
+		}
+
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(hunkOld, oldCount), hunkRange(hunkNew, newCount))
+		for _, o := range ops[start:end] {
+			sb.WriteByte(byte(o.kind))
+			sb.WriteString(o.line)
+			if !strings.HasSuffix(o.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+
+		oldLine, newLine = hunkOld+oldCount, hunkNew+newCount
+		i = end
+	}
+
+	return sb.String()
+}
+
+// hunkRange formats the range of a hunk, start is the number of lines before the hunk
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+
+	if count == 1 {
+		return fmt.Sprintf("%d", start+1)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// splitLines splits a text in lines, keeping their line feed
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// edits computes the shortest edit script from the longest common subsequence of lines
+func edits(oldLines, newLines []string) []op {
+	// lcs[i][j] is the length of the longest common subsequence of oldLines[i:] and newLines[j:]
+	lcs := make([][]int, len(oldLines)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(newLines)+1)
+	}
+
+	for i := len(oldLines) - 1; i >= 0; i-- {
+		for j := len(newLines) - 1; j >= 0; j-- {
+			if oldLines[i] == newLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]op, 0, len(oldLines)+len(newLines))
+	i, j := 0, 0
+	for i < len(oldLines) && j < len(newLines) {
+		switch {
+		case oldLines[i] == newLines[j]:
+			ops = append(ops, op{opEqual, oldLines[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, op{opDelete, oldLines[i]})
+			i++
+		default:
+			ops = append(ops, op{opInsert, newLines[j]})
+			j++
+		}
+	}
+
+	for ; i < len(oldLines); i++ {
+		ops = append(ops, op{opDelete, oldLines[i]})
+	}
+
+	for ; j < len(newLines); j++ {
+		ops = append(ops, op{opInsert, newLines[j]})
+	}
+
+	return ops
+}
@@ -0,0 +1,102 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package diff
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name    string
+		oldText string
+		newText string
+		result  string
+	}{
+		{
+			"Equal texts",
+			"a\nb\n",
+			"a\nb\n",
+			"",
+		},
+		{
+			"Changed line",
+			"a\nb\nc\n",
+			"a\nB\nc\n",
+			`--- old
++++ new
+@@ -1,3 +1,3 @@
+ a
+-b
++B
+ c
+`,
+		},
+		{
+			"New file",
+			"",
+			"a\nb\n",
+			`--- old
++++ new
+@@ -0,0 +1,2 @@
++a
++b
+`,
+		},
+		{
+			"Distant changes in two hunks",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			"0\n2\n3\n4\n5\n6\n7\n8\n9\n",
+			`--- old
++++ new
+@@ -1,4 +1,4 @@
+-1
++0
+ 2
+ 3
+ 4
+@@ -7,4 +7,3 @@
+ 7
+ 8
+ 9
+-10
+`,
+		},
+		{
+			"Missing final line feed",
+			"a\n",
+			"a",
+			`--- old
++++ new
+@@ -1 +1 @@
+-a
++a
+\ No newline at end of file
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := Unified("old", "new", test.oldText, test.newText)
+			if !reflect.DeepEqual(result, test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", result, test.result)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gendoc
+
+import (
+	"errors"
+	"fmt"
+	"github.com/raskyld/go-tektasker/internal/ir"
+	ttmarkers "github.com/raskyld/go-tektasker/pkg/markers"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"log/slog"
+	"path"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+// Generator writes the README of every Task in <name>/README.md
+type Generator struct {
+	Logger *slog.Logger
+
+	// Builder builds the intermediate representation of the tasks
+	Builder *ir.Builder
+
+	// APIVersion is the Tekton API version of the usage examples
+	APIVersion schema.GroupVersion
+
+	// ManifestDir is the directory holding the Task manifests,
+	// the install section is left out when it is empty
+	ManifestDir string
+}
+
+func (Generator) RegisterMarkers(into *markers.Registry) error {
+	return ttmarkers.Register(into)
+}
+
+func (g Generator) Generate(ctx *genall.GenerationContext) error {
+	for _, pkg := range ctx.Roots {
+		taskIR, err := g.Builder.Build(ctx.Collector, pkg)
+		if errors.Is(err, ir.ErrInvalidTask) {
+			// diagnostics are reported on the package
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if taskIR == nil || taskIR.StepAction {
+			continue
+		}
+
+		var manifest string
+		if g.ManifestDir != "" {
+			manifest = path.Join(g.ManifestDir, taskIR.Name+"-task.yaml")
+		}
+
+		g.Logger.Info("generating file", "file", path.Join(taskIR.Name, "README.md"))
+
+		readme, err := ctx.Open(nil, path.Join(taskIR.Name, "README.md"))
+		if err != nil {
+			return err
+		}
+
+		err = Render(readme, taskIR, manifest, g.APIVersion)
+		if closeErr := readme.Close(); err == nil {
+			err = closeErr
+		}
+
+		if err != nil {
+			return fmt.Errorf("unable to write the README of task %s: %w", taskIR.Name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package gendoc renders the Markdown reference of a Task from its
+// intermediate representation.
+package gendoc
+
+import (
+	"encoding/json"
+	"github.com/raskyld/go-tektasker/internal/ir"
+	"github.com/raskyld/go-tektasker/internal/sample"
+	"io"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
+	"strings"
+	"text/template"
+)
+
+const ReadmeName = "readme"
+
+const ReadmeTpl = `# {{if .Task.DisplayName}}{{.Task.DisplayName}}{{else}}{{.Task.Name}}{{end}}
+{{- with .Task.Description}}
+
+{{trim .}}
+{{- end}}
+
+{{- with .Manifest}}
+
+## Install the Task
+
+` + "```" + `shell
+kubectl apply -f {{.}}
+` + "```" + `
+{{- end}}
+{{- with .Task.MinPipelinesVersion}}
+
+It requires Tekton Pipelines {{.}} or later.
+{{- end}}
+{{- with .Task.Platforms}}
+
+It runs on the following platforms: {{join . ", "}}.
+{{- end}}
+{{- with .Task.Params}}
+
+## Parameters
+
+| Name | Type | Default | Description |
+| ---- | ---- | ------- | ----------- |
+{{- range .}}
+| ` + "`{{.Name}}`" + ` | {{.Type}} | {{if ne .Default nil}}` + "`{{cell (value .Default)}}`" + `{{else}}*required*{{end}} | {{cell .Description}}{{if and (cell .Description) (or .Enum .Pattern)}}<br>{{end}}{{with .Enum}}One of ` + "`{{cell (join . \"`, `\")}}`" + `.{{end}}{{if and .Enum .Pattern}} {{end}}{{with .Pattern}}Must match ` + "`{{cell .}}`" + `.{{end}} |
+{{- end}}
+{{- end}}
+{{- with .Task.Results}}
+
+## Results
+
+| Name | Type | Description |
+| ---- | ---- | ----------- |
+{{- range .}}
+| ` + "`{{.Name}}`" + ` | {{.Type}} | {{cell .Description}} |
+{{- end}}
+{{- end}}
+{{- with .Task.Workspaces}}
+
+## Workspaces
+
+| Name | Optional | Read-only | Description |
+| ---- | -------- | --------- | ----------- |
+{{- range .}}
+| ` + "`{{.Name}}`" + ` | {{.Optional}} | {{.ReadOnly}} | {{cell .Description}} |
+{{- end}}
+{{- end}}
+{{- if or .TaskRun .Pipeline}}
+
+## Usage
+{{- end}}
+{{- with .TaskRun}}
+
+Run the Task once, the placeholders must be replaced by the values of the required params:
+
+` + "```" + `yaml
+{{.}}` + "```" + `
+{{- end}}
+{{- with .Pipeline}}
+
+Use the Task in a Pipeline and pass its results to the next task:
+
+` + "```" + `yaml
+{{.}}` + "```" + `
+{{- end}}
+`
+
+// ReadmeArgs are the arguments of the ReadmeTpl template
+type ReadmeArgs struct {
+	Task *ir.Task
+
+	// Manifest is the path of the Task manifest to apply, the install section
+	// is left out when it is empty
+	Manifest string
+
+	// TaskRun and Pipeline are the YAML of the usage examples
+	TaskRun  string
+	Pipeline string
+}
+
+var readmeTemplate = template.Must(template.New(ReadmeName).Funcs(Funcs).Parse(ReadmeTpl))
+
+// Funcs are the functions available to the templates of the package
+var Funcs = template.FuncMap{
+	"join":  strings.Join,
+	"trim":  strings.TrimSpace,
+	"cell":  cell,
+	"value": value,
+}
+
+// Render writes the README of the Task, manifest is the path of its manifest
+// and gv is the Tekton API version of the usage examples
+func Render(w io.Writer, task *ir.Task, manifest string, gv schema.GroupVersion) error {
+	taskRun, err := yaml.Marshal(sample.TaskRun(task, gv).Object)
+	if err != nil {
+		return err
+	}
+
+	pipeline, err := yaml.Marshal(sample.Pipeline(task, gv).Object)
+	if err != nil {
+		return err
+	}
+
+	return readmeTemplate.Execute(w, ReadmeArgs{
+		Task:     task,
+		Manifest: manifest,
+		TaskRun:  string(taskRun),
+		Pipeline: string(pipeline),
+	})
+}
+
+// value formats the value of a param, strings are kept as is and the others,
+// along with the empty string which would not show, are given as JSON
+func value(v interface{}) (string, error) {
+	if str, ok := v.(string); ok && str != "" {
+		return str, nil
+	}
+
+	buf, err := json.Marshal(v)
+	return string(buf), err
+}
+
+// cell turns a text into something that fits in a cell of a Markdown table
+func cell(text string) string {
+	text = strings.TrimSpace(text)
+	text = strings.ReplaceAll(text, "|", "\\|")
+	return strings.Join(strings.Fields(text), " ")
+}
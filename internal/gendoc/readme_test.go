@@ -0,0 +1,222 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gendoc
+
+import (
+	"bytes"
+	"github.com/raskyld/go-tektasker/internal/ir"
+	"reflect"
+	"testing"
+	"text/template"
+)
+
+func TestReadme(t *testing.T) {
+	tpl, err := template.New(ReadmeName).Funcs(Funcs).Parse(ReadmeTpl)
+	if err != nil {
+		t.Errorf("couldnt create template %s: %s", ReadmeName, err.Error())
+	}
+
+	tests := []struct {
+		name    string
+		args    ReadmeArgs
+		wantErr bool
+		result  string
+	}{
+		{
+			"Bare task",
+			ReadmeArgs{
+				Task: &ir.Task{
+					Name:    "hello",
+					Version: "0.1",
+				},
+				Manifest: "task/hello/0.1/hello.yaml",
+			},
+			false,
+			"# hello\n\n## Install the Task\n\n```shell\nkubectl apply -f task/hello/0.1/hello.yaml\n```\n",
+		},
+		{
+			"Usage without install",
+			ReadmeArgs{
+				Task: &ir.Task{
+					Name:    "hello",
+					Version: "0.1",
+				},
+				TaskRun:  "kind: TaskRun\n",
+				Pipeline: "kind: Pipeline\n",
+			},
+			false,
+			"# hello\n\n## Usage\n\nRun the Task once, the placeholders must be replaced by the values of the required params:\n\n" +
+				"```yaml\nkind: TaskRun\n```\n\nUse the Task in a Pipeline and pass its results to the next task:\n\n" +
+				"```yaml\nkind: Pipeline\n```\n",
+		},
+		{
+			"Empty defaults",
+			ReadmeArgs{
+				Task: &ir.Task{
+					Name:    "hello",
+					Version: "0.1",
+					Params: []*ir.Param{
+						{
+							Name:    "prefix",
+							Type:    ir.TypeString,
+							Default: "",
+						},
+						{
+							Name:    "files",
+							Type:    ir.TypeArray,
+							Default: []interface{}{},
+						},
+						{
+							Name:    "labels",
+							Type:    ir.TypeObject,
+							Default: map[string]interface{}{},
+						},
+					},
+				},
+			},
+			false,
+			"# hello\n\n## Parameters\n\n| Name | Type | Default | Description |\n| ---- | ---- | ------- | ----------- |\n" +
+				"| `prefix` | string | `\"\"` |  |\n" +
+				"| `files` | array | `[]` |  |\n" +
+				"| `labels` | object | `{}` |  |\n",
+		},
+		{
+			"Restricted params",
+			ReadmeArgs{
+				Task: &ir.Task{
+					Name:    "hello",
+					Version: "0.1",
+					Params: []*ir.Param{
+						{
+							Name:        "timeout",
+							Type:        ir.TypeString,
+							Description: "Timeout of the greeting.\n",
+							Default:     "5m",
+							Enum:        []string{"5m", "1h"},
+							Pattern:     "^[0-9hm]+$",
+						},
+						{
+							Name:    "env",
+							Type:    ir.TypeString,
+							Default: "prod",
+							Enum:    []string{"staging", "prod"},
+						},
+						{
+							Name:        "tag",
+							Type:        ir.TypeString,
+							Description: "Tag to greet",
+							Pattern:     `^v\d+$`,
+						},
+					},
+				},
+			},
+			false,
+			"# hello\n\n## Parameters\n\n| Name | Type | Default | Description |\n| ---- | ---- | ------- | ----------- |\n" +
+				"| `timeout` | string | `5m` | Timeout of the greeting.<br>One of `5m`, `1h`. Must match `^[0-9hm]+$`. |\n" +
+				"| `env` | string | `prod` | One of `staging`, `prod`. |\n" +
+				"| `tag` | string | *required* | Tag to greet<br>Must match `^v\\d+$`. |\n",
+		},
+		{
+			"Full task",
+			ReadmeArgs{
+				Task: &ir.Task{
+					Name:                "hello",
+					Version:             "0.1",
+					DisplayName:         "Hello World",
+					Description:         "Package main says hello.\n",
+					MinPipelinesVersion: "0.50.0",
+					Platforms:           []string{"linux/amd64", "linux/arm64"},
+					Params: []*ir.Param{
+						{
+							Name:        "who",
+							Type:        ir.TypeString,
+							Description: "Who to greet,\neither a name | a nickname\n",
+							Default:     "world",
+						},
+						{
+							Name:        "files",
+							Type:        ir.TypeArray,
+							Description: "Files to read",
+							Default:     []interface{}{"a", "b"},
+						},
+						{
+							Name: "count",
+							Type: ir.TypeString,
+						},
+					},
+					Results: []*ir.Result{
+						{
+							Name:        "greeting",
+							Type:        ir.TypeString,
+							Description: "Greeting is the result",
+						},
+					},
+					Workspaces: []*ir.Workspace{
+						{
+							Name:        "src",
+							Description: "sources",
+							ReadOnly:    true,
+						},
+					},
+				},
+				Manifest: "task/hello/0.1/hello.yaml",
+			},
+			false,
+			"# Hello World\n\nPackage main says hello.\n\n## Install the Task\n\n```shell\nkubectl apply -f task/hello/0.1/hello.yaml\n```\n" +
+				`
+It requires Tekton Pipelines 0.50.0 or later.
+
+It runs on the following platforms: linux/amd64, linux/arm64.
+
+## Parameters
+
+| Name | Type | Default | Description |
+| ---- | ---- | ------- | ----------- |
+| ` + "`who`" + ` | string | ` + "`world`" + ` | Who to greet, either a name \| a nickname |
+| ` + "`files`" + ` | array | ` + "`[\"a\",\"b\"]`" + ` | Files to read |
+| ` + "`count`" + ` | string | *required* |  |
+
+## Results
+
+| Name | Type | Description |
+| ---- | ---- | ----------- |
+| ` + "`greeting`" + ` | string | Greeting is the result |
+
+## Workspaces
+
+| Name | Optional | Read-only | Description |
+| ---- | -------- | --------- | ----------- |
+| ` + "`src`" + ` | false | true | sources |
+`,
+		},
+	}
+
+	var buffer bytes.Buffer
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer buffer.Reset()
+			err := tpl.ExecuteTemplate(&buffer, ReadmeName, test.args)
+			if test.wantErr && err == nil {
+				t.Error("should have failed")
+			}
+
+			if !reflect.DeepEqual(buffer.String(), test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", buffer.String(), test.result)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gengo
+
+const EnvTypeName = "env.type"
+
+const EnvTypeTpl = `// Env is where parameters are read from and results are written to
+type Env interface {
+	// LookupEnv retrieves the value of an environment variable
+	LookupEnv(key string) (string, bool)
+
+	// Args are the arguments of the step, without the program name
+	Args() []string
+
+	// WriteFile writes the content of a result file
+	WriteFile(path string, data []byte) error
+}
+
+// OSEnv is the Env of the running process, it is the one used by Read and Write
+type OSEnv struct{}
+
+func (OSEnv) LookupEnv(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+func (OSEnv) Args() []string {
+	return os.Args[1:]
+}
+
+func (OSEnv) WriteFile(path string, data []byte) error {
+	return os.WriteFile(path, data, 0666)
+}
+
+// MemoryEnv is an Env held in memory, the result files are
+// written to Files indexed by their path
+type MemoryEnv struct {
+	Vars      map[string]string
+	Arguments []string
+	Files     map[string][]byte
+
+	mu sync.Mutex
+}
+
+// NewMemoryEnv returns an empty MemoryEnv
+func NewMemoryEnv() *MemoryEnv {
+	return &MemoryEnv{
+		Vars:  make(map[string]string),
+		Files: make(map[string][]byte),
+	}
+}
+
+func (e *MemoryEnv) LookupEnv(key string) (string, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	value, ok := e.Vars[key]
+	return value, ok
+}
+
+func (e *MemoryEnv) Args() []string {
+	return e.Arguments
+}
+
+func (e *MemoryEnv) WriteFile(path string, data []byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.Files == nil {
+		e.Files = make(map[string][]byte)
+	}
+
+	e.Files[path] = append([]byte(nil), data...)
+	return nil
+}
+`
@@ -0,0 +1,92 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gengo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEnv(t *testing.T) {
+	results := runGenerated(t, []string{"fmt", "os", "path/filepath"}, `
+type Greeting string
+
+func (g Greeting) Marshal() ([]byte, error) {
+	return []byte(g), nil
+}
+
+func (*Greeting) Unmarshal([]byte) error {
+	return nil
+}
+
+func (Greeting) Name() string {
+	return "greeting"
+}
+
+func (Greeting) EnvVar() string {
+	return "RESULT_GREETING_PATH"
+}
+
+func main() {
+	hello, hi := Greeting("hello"), Greeting("hi")
+
+	dir, err := os.MkdirTemp("", "env")
+	if err != nil {
+		panic(err)
+	}
+
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "greeting")
+
+	memory := NewMemoryEnv()
+	for key, value := range map[string]string{"SET": "value", "EMPTY": "", "RESULT_GREETING_PATH": path} {
+		os.Setenv(key, value)
+		memory.Vars[key] = value
+	}
+
+	for _, key := range []string{"SET", "EMPTY", "UNSET"} {
+		osValue, osOk := OSEnv{}.LookupEnv(key)
+		memoryValue, memoryOk := memory.LookupEnv(key)
+		fmt.Printf("%s %q %v %q %v\n", key, osValue, osOk, memoryValue, memoryOk)
+	}
+
+	err = WriteTo(memory, &hello)
+	_, statErr := os.Stat(path)
+	fmt.Printf("memory %v %q %v\n", err, memory.Files[path], os.IsNotExist(statErr))
+
+	err = Write(&hi)
+	written, _ := os.ReadFile(path)
+	fmt.Printf("os %v %q %d\n", err, written, len(memory.Files))
+
+	err = WriteTo(NewMemoryEnv(), &hello)
+	fmt.Printf("missing %v\n", err)
+}
+`)
+
+	wanted := []string{
+		`SET "value" true "value" true`,
+		`EMPTY "" true "" true`,
+		`UNSET "" false "" false`,
+		`memory <nil> "hello" true`,
+		`os <nil> "hi" 1`,
+		`missing result greeting path could not be loaded (RESULT_GREETING_PATH is missing)`,
+	}
+
+	if !reflect.DeepEqual(results, wanted) {
+		t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", results, wanted)
+	}
+}
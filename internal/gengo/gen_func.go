@@ -18,12 +18,14 @@ package gengo
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
-	"go/ast"
 	"log/slog"
+	"sort"
 	"strings"
 	"text/template"
 
+	"github.com/raskyld/go-tektasker/internal/ir"
 	ttmarkers "github.com/raskyld/go-tektasker/pkg/markers"
 	"sigs.k8s.io/controller-tools/pkg/genall"
 	"sigs.k8s.io/controller-tools/pkg/markers"
@@ -39,12 +41,24 @@ const FuncTpl = `{{template "%s" .GoHeaderArgs}}
 {{- end}}
 `
 
+// jsonTemplates are the templates whose generated code uses encoding/json
+var jsonTemplates = []string{
+	ParamFuncUnmarshalJSONName,
+	ParamFuncUnmarshalArrayJSONName,
+	ResultFuncMarshalJSONName,
+	ResultFuncMarshalPropertiesName,
+	ResultFuncUnmarshalJSONName,
+}
+
 // TaskGoFuncGenerator is the generator in charge of generating Go files for tasks
 // ATM, it generates both the internal Tektasker packages and makes user types implement
 // Interface awaited by Helper functions
 type TaskGoFuncGenerator struct {
 	Logger *slog.Logger
 
+	// Builder builds the intermediate representation of the tasks
+	Builder *ir.Builder
+
 	Template *template.Template
 
 	// HeaderFile path to add at the top of every generated go file
@@ -68,9 +82,10 @@ type FuncArgs struct {
 	TemplatesArgs PerTemplateArgs
 }
 
-func NewGoFunc(logger *slog.Logger, headerFile, year string) (*TaskGoFuncGenerator, error) {
+func NewGoFunc(logger *slog.Logger, builder *ir.Builder, headerFile, year string) (*TaskGoFuncGenerator, error) {
 	g := &TaskGoFuncGenerator{
 		Logger:     logger.With("generator", "goFunc"),
+		Builder:    builder,
 		Template:   &template.Template{},
 		HeaderFile: headerFile,
 		Year:       year,
@@ -92,11 +107,26 @@ func NewGoFunc(logger *slog.Logger, headerFile, year string) (*TaskGoFuncGenerat
 
 	g.RegisterTemplate(GoHeaderName, GoHeaderTpl).
 		RegisterTemplate(ParamFuncNameName, ParamFuncNameTpl).
+		RegisterTemplate(ParamFuncEnvVarName, ParamFuncEnvVarTpl).
 		RegisterTemplate(ParamFuncUnmarshalSimpleName, ParamFuncUnmarshalSimpleTpl).
 		RegisterTemplate(ParamFuncUnmarshalJSONName, ParamFuncUnmarshalJSONTpl).
+		RegisterTemplate(ParamFuncUnmarshalScalarName, ParamFuncUnmarshalScalarTpl).
+		RegisterTemplate(ParamFuncUnmarshalArraySimpleName, ParamFuncUnmarshalArraySimpleTpl).
+		RegisterTemplate(ParamFuncUnmarshalArrayJSONName, ParamFuncUnmarshalArrayJSONTpl).
+		RegisterTemplate(ParamFuncUnmarshalArrayScalarName, ParamFuncUnmarshalArrayScalarTpl).
+		RegisterTemplate(ParamFuncValidateName, ParamFuncValidateTpl).
+		RegisterTemplate(ParamFuncUnmarshalPropertiesName, ParamFuncUnmarshalPropertiesTpl).
+		RegisterTemplate(ParamFuncPropertyEnvVarsName, ParamFuncPropertyEnvVarsTpl).
 		RegisterTemplate(ResultFuncNameName, ResultFuncNameTpl).
+		RegisterTemplate(ResultFuncEnvVarName, ResultFuncEnvVarTpl).
 		RegisterTemplate(ResultFuncMarshalSimpleName, ResultFuncMarshalSimpleTpl).
 		RegisterTemplate(ResultFuncMarshalJSONName, ResultFuncMarshalJSONTpl).
+		RegisterTemplate(ResultFuncMarshalScalarName, ResultFuncMarshalScalarTpl).
+		RegisterTemplate(ResultFuncMarshalPropertiesName, ResultFuncMarshalPropertiesTpl).
+		RegisterTemplate(ResultFuncUnmarshalSimpleName, ResultFuncUnmarshalSimpleTpl).
+		RegisterTemplate(ResultFuncUnmarshalJSONName, ResultFuncUnmarshalJSONTpl).
+		RegisterTemplate(ResultFuncUnmarshalScalarName, ResultFuncUnmarshalScalarTpl).
+		RegisterTemplate(StepFuncDispatchName, StepFuncDispatchTpl).
 		RegisterTemplate(FuncName, fmt.Sprintf(FuncTpl, GoHeaderName))
 
 	return g, nil
@@ -130,134 +160,212 @@ func (g *TaskGoFuncGenerator) Generate(ctx *genall.GenerationContext) error {
 	}
 
 	for _, pkg := range ctx.Roots {
-		logger := g.Logger.With("pkg", pkg.Name)
-		logger.Debug("starting collecting")
+		task, err := g.Builder.Build(ctx.Collector, pkg)
+		if errors.Is(err, ir.ErrInvalidTask) {
+			// diagnostics are reported on the package
+			continue
+		}
 
-		// Check the package-level task marker is present or skip
-		pkgMarkers, err := markers.PackageMarkers(ctx.Collector, pkg)
 		if err != nil {
 			return err
 		}
 
-		taskMarker := pkgMarkers.Get(ttmarkers.MarkerTask)
-		if taskMarker == nil {
-			// If no task marker is set on package, simply skip it
-			logger.Info("skipping non-task package")
+		if task == nil {
 			continue
 		}
 
-		// Prepare the per-template per-param/result args mappings
-		perTemplateArgs := PerTemplateArgs(make(map[string]map[string]interface{}))
-		for _, t := range g.Template.Templates() {
-			// NB(raskyld): we need to exclude the main template as
-			// this would lead to infinite recursive call as we iterate
-			// over the map perTemplateArgs and call every template
-			if t.Name() == FuncName {
-				continue
-			}
+		output, err := ctx.OutputRule.Open(pkg, "zz_generated.tektasker.go")
+		if err != nil {
+			return err
+		}
 
-			perTemplateArgs[t.Name()] = make(map[string]interface{})
+		err = g.Template.ExecuteTemplate(output, FuncName, g.funcArgs(task, headerText))
+		if err != nil {
+			return err
 		}
+	}
 
-		err = markers.EachType(ctx.Collector, pkg, func(info *markers.TypeInfo) {
-			rawParam := info.Markers.Get(ttmarkers.MarkerParam)
-			if rawParam != nil {
-				if param, ok := rawParam.(ttmarkers.Param); ok {
-					logger := logger.With("param", param.Name)
-					logger.Info("parameter found")
-
-					// ensure no duplication
-					if _, duplicate := perTemplateArgs[ParamFuncNameName][param.Name]; duplicate {
-						logger.Warn("parameter duplicated! ensure unique name")
-						return
-					}
-
-					perTemplateArgs[ParamFuncNameName][param.Name] = ParamFuncArgs{
-						ParamName: param.Name,
-						ParamType: info.Name,
-					}
-
-					// TODO(raskyld):
-					// 	add a way to skip creating the Unmarshal method so our
-					// 	users can create a custom unmarshaler for their complex types
-					funcTemplateToUse := ParamFuncUnmarshalJSONName
-
-					// NB(raskyld): this is for ease of use when we have a type made of string
-					// otherwise, we just expect the value to be valid JSON
-					if ident, ok := info.RawSpec.Type.(*ast.Ident); ok {
-						if ident.Name == "string" {
-							funcTemplateToUse = ParamFuncUnmarshalSimpleName
-						}
-					}
-
-					perTemplateArgs[funcTemplateToUse][param.Name] = ParamFuncArgs{
-						ParamName: param.Name,
-						ParamType: info.Name,
-					}
-				}
-			}
+	return nil
+}
 
-			rawResult := info.Markers.Get(ttmarkers.MarkerResult)
-			if rawResult != nil {
-				if result, ok := rawResult.(ttmarkers.Result); ok {
-					logger := logger.With("result", result.Name)
-					logger.Info("result found")
-
-					// ensure no duplication
-					if _, duplicate := perTemplateArgs[ResultFuncNameName][result.Name]; duplicate {
-						logger.Warn("result duplicated! ensure unique name")
-						return
-					}
-
-					perTemplateArgs[ResultFuncNameName][result.Name] = ResultFuncArgs{
-						ResultName: result.Name,
-						ResultType: info.Name,
-					}
-
-					funcTemplateToUse := ResultFuncMarshalJSONName
-
-					// NB(raskyld): this is for ease of use when we have a type made of string
-					// otherwise, we just expect the value to be valid JSON
-					if ident, ok := info.RawSpec.Type.(*ast.Ident); ok {
-						if ident.Name == "string" {
-							funcTemplateToUse = ResultFuncMarshalSimpleName
-						}
-					}
-
-					perTemplateArgs[funcTemplateToUse][result.Name] = ResultFuncArgs{
-						ResultName: result.Name,
-						ResultType: info.Name,
-					}
-				}
+// funcArgs computes the arguments of the templates generating the methods of the params,
+// the results and the steps of the task along with the packages they import
+func (g *TaskGoFuncGenerator) funcArgs(task *ir.Task, headerText string) FuncArgs {
+	// Prepare the per-template per-param/result args mappings
+	perTemplateArgs := PerTemplateArgs(make(map[string]map[string]interface{}))
+	for _, t := range g.Template.Templates() {
+		// NB(raskyld): we need to exclude the main template as
+		// this would lead to infinite recursive call as we iterate
+		// over the map perTemplateArgs and call every template
+		if t.Name() == FuncName {
+			continue
+		}
+
+		perTemplateArgs[t.Name()] = make(map[string]interface{})
+	}
+
+	// scalarImportPaths are the packages used to parse and format the scalars
+	scalarImportPaths := make(map[string]struct{})
+
+	for _, param := range task.Params {
+		args := ParamFuncArgs{
+			ParamName:  param.Name,
+			ParamType:  param.GoType,
+			EnvVar:     param.EnvVar,
+			Scalar:     NewScalarArgs(param.Scalar),
+			ItemScalar: NewScalarArgs(param.ItemScalar),
+			Enum:       param.Enum,
+			Pattern:    param.Pattern,
+		}
+
+		for _, property := range param.Properties {
+			args.Properties = append(args.Properties, PropertyArgs{
+				Name:     property.Name,
+				Field:    property.Field,
+				EnvVar:   property.EnvVar,
+				Required: property.Required,
+			})
+		}
+
+		perTemplateArgs[ParamFuncNameName][param.Name] = args
+		perTemplateArgs[ParamFuncEnvVarName][param.Name] = args
+
+		// TODO(raskyld):
+		// 	add a way to skip creating the Unmarshal method so our
+		// 	users can create a custom unmarshaler for their complex types
+		funcTemplateToUse := ParamFuncUnmarshalJSONName
+		switch param.Encoding {
+		case ir.EncodingRaw:
+			funcTemplateToUse = ParamFuncUnmarshalSimpleName
+		case ir.EncodingScalar:
+			funcTemplateToUse = ParamFuncUnmarshalScalarName
+			scalarImportPaths[args.Scalar.ImportPath] = struct{}{}
+		}
+
+		perTemplateArgs[funcTemplateToUse][param.Name] = args
+
+		if len(param.Enum) > 0 || param.Pattern != "" {
+			perTemplateArgs[ParamFuncValidateName][param.Name] = args
+		}
+
+		if param.Type == ir.TypeArray {
+			arrayTemplateToUse := ParamFuncUnmarshalArrayJSONName
+			switch param.ItemEncoding {
+			case ir.EncodingRaw:
+				arrayTemplateToUse = ParamFuncUnmarshalArraySimpleName
+			case ir.EncodingScalar:
+				arrayTemplateToUse = ParamFuncUnmarshalArrayScalarName
+				scalarImportPaths[args.ItemScalar.ImportPath] = struct{}{}
 			}
-		})
-		if err != nil {
-			return err
+
+			perTemplateArgs[arrayTemplateToUse][param.Name] = args
 		}
 
-		output, err := ctx.OutputRule.Open(pkg, "zz_generated.tektasker.go")
-		if err != nil {
-			return err
+		if param.Type == ir.TypeObject {
+			perTemplateArgs[ParamFuncUnmarshalPropertiesName][param.Name] = args
+			perTemplateArgs[ParamFuncPropertyEnvVarsName][param.Name] = args
+		}
+	}
+
+	for _, result := range task.Results {
+		args := ResultFuncArgs{
+			ResultName: result.Name,
+			ResultType: result.GoType,
+			EnvVar:     result.EnvVar,
+			Scalar:     NewScalarArgs(result.Scalar),
+		}
+
+		perTemplateArgs[ResultFuncNameName][result.Name] = args
+		perTemplateArgs[ResultFuncEnvVarName][result.Name] = args
+
+		for _, property := range result.Properties {
+			args.Properties = append(args.Properties, PropertyArgs{
+				Name:  property.Name,
+				Field: property.Field,
+			})
+		}
+
+		funcTemplateToUse := ResultFuncMarshalJSONName
+		if result.Type == ir.TypeObject {
+			funcTemplateToUse = ResultFuncMarshalPropertiesName
+		} else if result.Encoding == ir.EncodingRaw {
+			funcTemplateToUse = ResultFuncMarshalSimpleName
+		} else if result.Encoding == ir.EncodingScalar {
+			funcTemplateToUse = ResultFuncMarshalScalarName
+		}
+
+		perTemplateArgs[funcTemplateToUse][result.Name] = args
+
+		unmarshalTemplateToUse := ResultFuncUnmarshalJSONName
+		switch result.Encoding {
+		case ir.EncodingRaw:
+			unmarshalTemplateToUse = ResultFuncUnmarshalSimpleName
+		case ir.EncodingScalar:
+			unmarshalTemplateToUse = ResultFuncUnmarshalScalarName
+			scalarImportPaths[args.Scalar.ImportPath] = struct{}{}
+		}
+
+		perTemplateArgs[unmarshalTemplateToUse][result.Name] = args
+	}
+
+	if task.Dispatched() {
+		dispatchArgs := StepFuncDispatchArgs{
+			EnvVar: ir.StepEnvVar,
+		}
+
+		for _, step := range task.Steps {
+			if step.Entrypoint != "" {
+				dispatchArgs.Steps = append(dispatchArgs.Steps, StepFuncArgs{
+					StepName:   step.Name,
+					Entrypoint: step.Entrypoint,
+				})
+			}
 		}
 
-		importPaths := make([]string, 0)
-		if len(perTemplateArgs[ResultFuncMarshalJSONName]) > 0 || len(perTemplateArgs[ParamFuncUnmarshalJSONName]) > 0 {
+		perTemplateArgs[StepFuncDispatchName][task.Name] = dispatchArgs
+	}
+
+	importPaths := make([]string, 0)
+	for _, tplName := range jsonTemplates {
+		if len(perTemplateArgs[tplName]) > 0 {
 			importPaths = append(importPaths, "encoding/json")
+			break
 		}
+	}
 
-		err = g.Template.ExecuteTemplate(output, FuncName, FuncArgs{
-			GoHeaderArgs: GoHeaderArgs{
-				PkgName:     pkg.Name,
-				Header:      headerText,
-				ImportPaths: importPaths,
-			},
-			TemplatesArgs: perTemplateArgs,
-		})
+	// only a missing required property is an error
+	for _, param := range task.Params {
+		if param.HasRequiredProperty() {
+			importPaths = append(importPaths, "errors")
+			break
+		}
+	}
 
-		if err != nil {
-			return err
+	if len(perTemplateArgs[StepFuncDispatchName]) > 0 {
+		importPaths = append(importPaths, "fmt", "os")
+	} else if len(perTemplateArgs[ParamFuncValidateName]) > 0 {
+		importPaths = append(importPaths, "fmt")
+	}
+
+	for _, param := range task.Params {
+		if param.Pattern != "" {
+			importPaths = append(importPaths, "regexp")
+			break
 		}
 	}
 
-	return nil
+	for importPath := range scalarImportPaths {
+		importPaths = append(importPaths, importPath)
+	}
+
+	sort.Strings(importPaths)
+
+	return FuncArgs{
+		GoHeaderArgs: GoHeaderArgs{
+			PkgName:     task.Package.Name,
+			Header:      headerText,
+			ImportPaths: importPaths,
+		},
+		TemplatesArgs: perTemplateArgs,
+	}
 }
@@ -18,9 +18,16 @@ package gengo
 
 import (
 	"bytes"
+	"fmt"
+	"github.com/raskyld/go-tektasker/internal/ir"
 	ttmarkers "github.com/raskyld/go-tektasker/pkg/markers"
+	"golang.org/x/mod/modfile"
 	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
 	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
 	"sigs.k8s.io/controller-tools/pkg/markers"
 	"strings"
 	"text/template"
@@ -32,20 +39,27 @@ type TaskGoInternalGenerator struct {
 	PackageName string
 	HeaderFile  string
 	Year        string
+
+	// OutputDir is the directory the package is written to, it is used to compute
+	// the import path of the package from the tektontest sub-package
+	OutputDir string
 }
 
-func NewGoInternal(logger *slog.Logger, pkgName, headerFile, year string) (*TaskGoInternalGenerator, error) {
+func NewGoInternal(logger *slog.Logger, pkgName, outputDir, headerFile, year string) (*TaskGoInternalGenerator, error) {
 	g := &TaskGoInternalGenerator{
 		Logger:      logger.With("generator", "goInternal"),
 		Template:    &template.Template{},
 		PackageName: pkgName,
 		HeaderFile:  headerFile,
 		Year:        year,
+		OutputDir:   outputDir,
 	}
 
 	g.RegisterTemplate(GoHeaderName, GoHeaderTpl).
+		RegisterTemplate(EnvTypeName, EnvTypeTpl).
 		RegisterTemplate(ParameterTypeName, ParameterTypeTpl).
-		RegisterTemplate(ResultTypeName, ResultTypeTpl)
+		RegisterTemplate(ResultTypeName, ResultTypeTpl).
+		RegisterTemplate(TektonTestName, TektonTestTpl)
 
 	return g, nil
 }
@@ -65,7 +79,6 @@ func (*TaskGoInternalGenerator) RegisterMarkers(into *markers.Registry) error {
 }
 
 func (g *TaskGoInternalGenerator) Generate(ctx *genall.GenerationContext) error {
-	var headerBytes bytes.Buffer
 	var headerText string
 
 	if g.HeaderFile != "" {
@@ -77,33 +90,55 @@ func (g *TaskGoInternalGenerator) Generate(ctx *genall.GenerationContext) error
 		headerText = strings.ReplaceAll(string(buf), " YEAR", " "+g.Year)
 	}
 
-	headerArgs := GoHeaderArgs{
-		PkgName: g.PackageName,
-		Header:  headerText,
-		ImportPaths: []string{
-			"errors",
-			"fmt",
-			"os",
-			"strings",
-		},
+	headerBytes, err := g.generateHeader(headerText, g.PackageName, "os", "sync")
+	if err != nil {
+		return err
 	}
 
-	g.Logger.Debug("generating header", "args", headerArgs)
-	err := g.Template.ExecuteTemplate(&headerBytes, GoHeaderName, headerArgs)
+	g.Logger.Info("generating file", "file", "env.go")
+	err = g.generatePkgFile(ctx, headerBytes, "env.go", EnvTypeName, nil)
 	if err != nil {
 		return err
 	}
 
-	headerBytes.WriteByte('\n')
+	headerBytes, err = g.generateHeader(headerText, g.PackageName, "errors", "fmt")
+	if err != nil {
+		return err
+	}
 
 	g.Logger.Info("generating file", "file", "result.go")
-	err = g.generatePkgFile(ctx, headerBytes, "result.go", ResultTypeName)
+	err = g.generatePkgFile(ctx, headerBytes, "result.go", ResultTypeName, nil)
 	if err != nil {
 		return err
 	}
 
 	g.Logger.Info("generating file", "file", "parameter.go")
-	err = g.generatePkgFile(ctx, headerBytes, "parameter.go", ParameterTypeName)
+	err = g.generatePkgFile(ctx, headerBytes, "parameter.go", ParameterTypeName, ParameterTypeArgs{
+		ArrayArgBegin: ir.ArrayArgBegin,
+		ArrayArgEnd:   ir.ArrayArgEnd,
+	})
+	if err != nil {
+		return err
+	}
+
+	importPath, err := importPathOf(ctx.Roots, g.OutputDir)
+	if err != nil {
+		return err
+	}
+
+	testHeaderBytes, err := g.generateHeader(headerText, TektonTestPkgName,
+		"encoding/json", "fmt", "os", "path/filepath", "testing", importPath)
+	if err != nil {
+		return err
+	}
+
+	testFile := path.Join(TektonTestPkgName, TektonTestPkgName+".go")
+	g.Logger.Info("generating file", "file", testFile)
+	err = g.generatePkgFile(ctx, testHeaderBytes, testFile, TektonTestName, TektonTestArgs{
+		PkgName:       g.PackageName,
+		ArrayArgBegin: ir.ArrayArgBegin,
+		ArrayArgEnd:   ir.ArrayArgEnd,
+	})
 	if err != nil {
 		return err
 	}
@@ -111,7 +146,74 @@ func (g *TaskGoInternalGenerator) Generate(ctx *genall.GenerationContext) error
 	return nil
 }
 
-func (g *TaskGoInternalGenerator) generatePkgFile(ctx *genall.GenerationContext, headerBytes bytes.Buffer, fileName string, tplName string) error {
+// generateHeader generates the header of a file of the package pkgName
+func (g *TaskGoInternalGenerator) generateHeader(headerText, pkgName string, importPaths ...string) (bytes.Buffer, error) {
+	var headerBytes bytes.Buffer
+
+	headerArgs := GoHeaderArgs{
+		PkgName:     pkgName,
+		Header:      headerText,
+		ImportPaths: importPaths,
+	}
+
+	g.Logger.Debug("generating header", "args", headerArgs)
+	err := g.Template.ExecuteTemplate(&headerBytes, GoHeaderName, headerArgs)
+	if err != nil {
+		return bytes.Buffer{}, err
+	}
+
+	headerBytes.WriteByte('\n')
+	return headerBytes, nil
+}
+
+// importPathOf computes the import path of dir from the location of the root packages
+// or, when they have no Go files, from the go.mod of its module
+func importPathOf(roots []*loader.Package, dir string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	for _, root := range roots {
+		if len(root.GoFiles) == 0 {
+			continue
+		}
+
+		rel, err := filepath.Rel(filepath.Dir(root.GoFiles[0]), absDir)
+		if err != nil {
+			continue
+		}
+
+		return path.Join(root.PkgPath, filepath.ToSlash(rel)), nil
+	}
+
+	// none of the roots has Go files, e.g. the root of a module
+	// whose tasks are all in sub-directories
+	for moduleDir := absDir; ; moduleDir = filepath.Dir(moduleDir) {
+		modFile, err := os.ReadFile(filepath.Join(moduleDir, "go.mod"))
+		if err == nil {
+			modulePath := modfile.ModulePath(modFile)
+			if modulePath == "" {
+				break
+			}
+
+			rel, err := filepath.Rel(moduleDir, absDir)
+			if err != nil {
+				return "", err
+			}
+
+			return path.Join(modulePath, filepath.ToSlash(rel)), nil
+		}
+
+		if filepath.Dir(moduleDir) == moduleDir {
+			break
+		}
+	}
+
+	return "", fmt.Errorf("could not compute the import path of %s from the input packages or their module", dir)
+}
+
+func (g *TaskGoInternalGenerator) generatePkgFile(ctx *genall.GenerationContext, headerBytes bytes.Buffer, fileName string, tplName string, args interface{}) error {
 	output, err := ctx.OutputRule.Open(nil, fileName)
 	if err != nil {
 		return err
@@ -122,7 +224,7 @@ func (g *TaskGoInternalGenerator) generatePkgFile(ctx *genall.GenerationContext,
 		return err
 	}
 
-	err = g.Template.ExecuteTemplate(output, tplName, nil)
+	err = g.Template.ExecuteTemplate(output, tplName, args)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,91 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gengo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/tools/go/packages"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+)
+
+func TestImportPathOf(t *testing.T) {
+	moduleDir := t.TempDir()
+	err := os.WriteFile(filepath.Join(moduleDir, "go.mod"), []byte("module example.com/tasks\n\ngo 1.21\n"), 0666)
+	if err != nil {
+		t.Fatalf("couldnt write go.mod: %s", err.Error())
+	}
+
+	// the root of the module has no Go files, its tasks are in sub-directories
+	root := &loader.Package{Package: &packages.Package{PkgPath: "example.com/tasks"}}
+	hello := &loader.Package{Package: &packages.Package{
+		PkgPath: "example.com/tasks/hello",
+		GoFiles: []string{filepath.Join(moduleDir, "hello", "main.go")},
+	}}
+
+	tests := []struct {
+		name    string
+		roots   []*loader.Package
+		dir     string
+		wantErr bool
+		result  string
+	}{
+		{
+			"Next to a root package",
+			[]*loader.Package{root, hello},
+			filepath.Join(moduleDir, "internal", "tektasker"),
+			false,
+			"example.com/tasks/internal/tektasker",
+		},
+		{
+			"Inside a root package",
+			[]*loader.Package{hello},
+			filepath.Join(moduleDir, "hello", "internal"),
+			false,
+			"example.com/tasks/hello/internal",
+		},
+		{
+			"No root with Go files",
+			[]*loader.Package{root},
+			filepath.Join(moduleDir, "internal", "tektasker"),
+			false,
+			"example.com/tasks/internal/tektasker",
+		},
+		{
+			"No root with Go files outside of a module",
+			[]*loader.Package{root},
+			filepath.Join(t.TempDir(), "internal"),
+			true,
+			"",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := importPathOf(test.roots, test.dir)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("importPathOf() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if result != test.result {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", result, test.result)
+			}
+		})
+	}
+}
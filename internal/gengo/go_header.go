@@ -20,6 +20,7 @@ const GoHeaderName = "go.header"
 
 const GoHeaderTpl = `{{- with .Header -}}
 {{.}}
+
 {{end -}}
 
 package {{.PkgName}}
@@ -23,6 +23,13 @@ const ParamFuncNameTpl = `func (param *{{.ParamType}}) Name() string {
 }
 `
 
+const ParamFuncEnvVarName = "param.func.envvar"
+
+const ParamFuncEnvVarTpl = `func (param *{{.ParamType}}) EnvVar() string {
+	return "{{.EnvVar}}"
+}
+`
+
 const ParamFuncUnmarshalSimpleName = "param.func.unmarshal.simple"
 
 const ParamFuncUnmarshalSimpleTpl = `func (param *{{.ParamType}}) Unmarshal(buf []byte) error {
@@ -38,7 +45,126 @@ const ParamFuncUnmarshalJSONTpl = `func (param *{{.ParamType}}) Unmarshal(buf []
 }
 `
 
+const ParamFuncUnmarshalScalarName = "param.func.unmarshal.scalar"
+
+const ParamFuncUnmarshalScalarTpl = `func (param *{{.ParamType}}) Unmarshal(buf []byte) error {
+	value, err := {{printf .Scalar.Parse "string(buf)"}}
+	if err != nil {
+		return err
+	}
+
+	*param = {{.ParamType}}(value)
+	return nil
+}
+`
+
+const ParamFuncUnmarshalArraySimpleName = "param.func.unmarshalarray.simple"
+
+const ParamFuncUnmarshalArraySimpleTpl = `func (param *{{.ParamType}}) UnmarshalArray(values []string) error {
+	*param = {{.ParamType}}(values)
+	return nil
+}
+`
+
+const ParamFuncUnmarshalArrayJSONName = "param.func.unmarshalarray.json"
+
+const ParamFuncUnmarshalArrayJSONTpl = `func (param *{{.ParamType}}) UnmarshalArray(values []string) error {
+	*param = make({{.ParamType}}, len(values))
+	for i, value := range values {
+		err := json.Unmarshal([]byte(value), &(*param)[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+`
+
+const ParamFuncUnmarshalArrayScalarName = "param.func.unmarshalarray.scalar"
+
+const ParamFuncUnmarshalArrayScalarTpl = `func (param *{{.ParamType}}) UnmarshalArray(values []string) error {
+	*param = make({{.ParamType}}, len(values))
+	for i, value := range values {
+		item, err := {{printf .ItemScalar.Parse "value"}}
+		if err != nil {
+			return err
+		}
+
+		(*param)[i] = {{.ItemScalar.Kind}}(item)
+	}
+
+	return nil
+}
+`
+
+const ParamFuncValidateName = "param.func.validate"
+
+// ParamFuncValidateTpl checks the value of a param restricted by an enum or a pattern,
+// the value is checked as read from the environment, like the defaults are when generating,
+// as formatting a scalar back may change it, e.g. 5m becomes 5m0s
+const ParamFuncValidateTpl = `func (param *{{.ParamType}}) Validate(value string) error {
+{{- with .Enum}}
+	switch value {
+	case {{range $i, $value := .}}{{if $i}}, {{end}}{{printf "%q" $value}}{{end}}:
+	default:
+		return fmt.Errorf("parameter {{$.ParamName}} must be one of {{range $i, $value := .}}{{if $i}}, {{end}}%q{{end}}, got %q",{{range .}} {{printf "%q" .}},{{end}} value)
+	}
+{{end}}
+{{- with .Pattern}}
+	if !regexp.MustCompile({{printf "%q" .}}).MatchString(value) {
+		return fmt.Errorf("parameter {{$.ParamName}} must match %s, got %q", {{printf "%q" .}}, value)
+	}
+{{end}}
+	return nil
+}
+`
+
+const ParamFuncUnmarshalPropertiesName = "param.func.unmarshalproperties"
+
+const ParamFuncUnmarshalPropertiesTpl = `func (param *{{.ParamType}}) UnmarshalProperties(lookup func(envVar string) (string, bool)) error {
+{{- range .Properties}}
+	if value, ok := lookup("{{.EnvVar}}"); ok {
+		param.{{.Field}} = value
+	}{{if .Required}} else {
+		return errors.New("property {{.Name}} of parameter {{$.ParamName}} is not in environment ({{.EnvVar}} is missing)")
+	}{{end}}
+{{- end}}
+
+	return nil
+}
+`
+
+const ParamFuncPropertyEnvVarsName = "param.func.propertyenvvars"
+
+const ParamFuncPropertyEnvVarsTpl = `func (param *{{.ParamType}}) PropertyEnvVars() map[string]string {
+	return map[string]string{
+{{- range .Properties}}
+		"{{.Name}}": "{{.EnvVar}}",
+{{- end}}
+	}
+}
+`
+
 type ParamFuncArgs struct {
-	ParamName string
-	ParamType string
+	ParamName  string
+	ParamType  string
+	EnvVar     string
+	Properties []PropertyArgs
+
+	// Scalar and ItemScalar are set for the params made of a builtin scalar
+	Scalar     *ScalarArgs
+	ItemScalar *ScalarArgs
+
+	// Enum and Pattern restrict the values of the param
+	Enum    []string
+	Pattern string
+}
+
+// PropertyArgs are the arguments of a key of an object param
+type PropertyArgs struct {
+	Name     string
+	Field    string
+	EnvVar   string
+	Required bool
 }
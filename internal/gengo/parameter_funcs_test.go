@@ -65,6 +65,49 @@ func TestParamFuncName(t *testing.T) {
 	}
 }
 
+func TestParamFuncEnvVar(t *testing.T) {
+	tpl, err := template.New(ParamFuncEnvVarName).Parse(ParamFuncEnvVarTpl)
+	if err != nil {
+		t.Errorf("couldnt create template %s: %s", ParamFuncEnvVarName, err.Error())
+	}
+
+	tests := []struct {
+		name    string
+		args    ParamFuncArgs
+		wantErr bool
+		result  string
+	}{
+		{
+			"Sanitised env var",
+			ParamFuncArgs{
+				ParamName: "git-url",
+				ParamType: "GitURL",
+				EnvVar:    "PARAM_GIT_URL_VALUE",
+			},
+			false,
+			`func (param *GitURL) EnvVar() string {
+	return "PARAM_GIT_URL_VALUE"
+}
+`,
+		},
+	}
+
+	var buffer bytes.Buffer
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer buffer.Reset()
+			err := tpl.ExecuteTemplate(&buffer, ParamFuncEnvVarName, test.args)
+			if test.wantErr && err == nil {
+				t.Error("should have failed")
+			}
+
+			if !reflect.DeepEqual(buffer.String(), test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", buffer.String(), test.result)
+			}
+		})
+	}
+}
+
 func TestParamFuncUnmarshalSimple(t *testing.T) {
 	tpl, err := template.New(ParamFuncUnmarshalSimpleName).Parse(ParamFuncUnmarshalSimpleTpl)
 	if err != nil {
@@ -149,3 +192,505 @@ func TestParamFuncUnmarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestParamFuncUnmarshalScalar(t *testing.T) {
+	tpl, err := template.New(ParamFuncUnmarshalScalarName).Parse(ParamFuncUnmarshalScalarTpl)
+	if err != nil {
+		t.Errorf("couldnt create template %s: %s", ParamFuncUnmarshalScalarName, err.Error())
+	}
+
+	tests := []struct {
+		name    string
+		args    ParamFuncArgs
+		wantErr bool
+		result  string
+	}{
+		{
+			"Int param",
+			ParamFuncArgs{
+				ParamName: "count",
+				ParamType: "Count",
+				Scalar:    NewScalarArgs("int"),
+			},
+			false,
+			`func (param *Count) Unmarshal(buf []byte) error {
+	value, err := strconv.ParseInt(string(buf), 10, 0)
+	if err != nil {
+		return err
+	}
+
+	*param = Count(value)
+	return nil
+}
+`,
+		},
+		{
+			"Bool param",
+			ParamFuncArgs{
+				ParamName: "dry-run",
+				ParamType: "DryRun",
+				Scalar:    NewScalarArgs("bool"),
+			},
+			false,
+			`func (param *DryRun) Unmarshal(buf []byte) error {
+	value, err := strconv.ParseBool(string(buf))
+	if err != nil {
+		return err
+	}
+
+	*param = DryRun(value)
+	return nil
+}
+`,
+		},
+		{
+			"Float param",
+			ParamFuncArgs{
+				ParamName: "ratio",
+				ParamType: "Ratio",
+				Scalar:    NewScalarArgs("float32"),
+			},
+			false,
+			`func (param *Ratio) Unmarshal(buf []byte) error {
+	value, err := strconv.ParseFloat(string(buf), 32)
+	if err != nil {
+		return err
+	}
+
+	*param = Ratio(value)
+	return nil
+}
+`,
+		},
+		{
+			"Duration param",
+			ParamFuncArgs{
+				ParamName: "timeout",
+				ParamType: "Timeout",
+				Scalar:    NewScalarArgs("time.Duration"),
+			},
+			false,
+			`func (param *Timeout) Unmarshal(buf []byte) error {
+	value, err := time.ParseDuration(string(buf))
+	if err != nil {
+		return err
+	}
+
+	*param = Timeout(value)
+	return nil
+}
+`,
+		},
+	}
+
+	var buffer bytes.Buffer
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer buffer.Reset()
+			err := tpl.ExecuteTemplate(&buffer, ParamFuncUnmarshalScalarName, test.args)
+			if test.wantErr && err == nil {
+				t.Error("should have failed")
+			}
+
+			if !reflect.DeepEqual(buffer.String(), test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", buffer.String(), test.result)
+			}
+		})
+	}
+}
+
+func TestParamFuncUnmarshalArraySimple(t *testing.T) {
+	tpl, err := template.New(ParamFuncUnmarshalArraySimpleName).Parse(ParamFuncUnmarshalArraySimpleTpl)
+	if err != nil {
+		t.Errorf("couldnt create template %s: %s", ParamFuncUnmarshalArraySimpleName, err.Error())
+	}
+
+	tests := []struct {
+		name    string
+		args    ParamFuncArgs
+		wantErr bool
+		result  string
+	}{
+		{
+			"Slice of string",
+			ParamFuncArgs{
+				ParamName: "files",
+				ParamType: "Files",
+			},
+			false,
+			`func (param *Files) UnmarshalArray(values []string) error {
+	*param = Files(values)
+	return nil
+}
+`,
+		},
+	}
+
+	var buffer bytes.Buffer
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer buffer.Reset()
+			err := tpl.ExecuteTemplate(&buffer, ParamFuncUnmarshalArraySimpleName, test.args)
+			if test.wantErr && err == nil {
+				t.Error("should have failed")
+			}
+
+			if !reflect.DeepEqual(buffer.String(), test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", buffer.String(), test.result)
+			}
+		})
+	}
+}
+
+func TestParamFuncUnmarshalArrayJSON(t *testing.T) {
+	tpl, err := template.New(ParamFuncUnmarshalArrayJSONName).Parse(ParamFuncUnmarshalArrayJSONTpl)
+	if err != nil {
+		t.Errorf("couldnt create template %s: %s", ParamFuncUnmarshalArrayJSONName, err.Error())
+	}
+
+	tests := []struct {
+		name    string
+		args    ParamFuncArgs
+		wantErr bool
+		result  string
+	}{
+		{
+			"Slice of int",
+			ParamFuncArgs{
+				ParamName: "files",
+				ParamType: "Ports",
+			},
+			false,
+			`func (param *Ports) UnmarshalArray(values []string) error {
+	*param = make(Ports, len(values))
+	for i, value := range values {
+		err := json.Unmarshal([]byte(value), &(*param)[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+`,
+		},
+	}
+
+	var buffer bytes.Buffer
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer buffer.Reset()
+			err := tpl.ExecuteTemplate(&buffer, ParamFuncUnmarshalArrayJSONName, test.args)
+			if test.wantErr && err == nil {
+				t.Error("should have failed")
+			}
+
+			if !reflect.DeepEqual(buffer.String(), test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", buffer.String(), test.result)
+			}
+		})
+	}
+}
+
+func TestParamFuncUnmarshalArrayScalar(t *testing.T) {
+	tpl, err := template.New(ParamFuncUnmarshalArrayScalarName).Parse(ParamFuncUnmarshalArrayScalarTpl)
+	if err != nil {
+		t.Errorf("couldnt create template %s: %s", ParamFuncUnmarshalArrayScalarName, err.Error())
+	}
+
+	tests := []struct {
+		name    string
+		args    ParamFuncArgs
+		wantErr bool
+		result  string
+	}{
+		{
+			"Slice of uint16",
+			ParamFuncArgs{
+				ParamName:  "ports",
+				ParamType:  "Ports",
+				ItemScalar: NewScalarArgs("uint16"),
+			},
+			false,
+			`func (param *Ports) UnmarshalArray(values []string) error {
+	*param = make(Ports, len(values))
+	for i, value := range values {
+		item, err := strconv.ParseUint(value, 10, 16)
+		if err != nil {
+			return err
+		}
+
+		(*param)[i] = uint16(item)
+	}
+
+	return nil
+}
+`,
+		},
+		{
+			"Slice of duration",
+			ParamFuncArgs{
+				ParamName:  "backoffs",
+				ParamType:  "Backoffs",
+				ItemScalar: NewScalarArgs("time.Duration"),
+			},
+			false,
+			`func (param *Backoffs) UnmarshalArray(values []string) error {
+	*param = make(Backoffs, len(values))
+	for i, value := range values {
+		item, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+
+		(*param)[i] = time.Duration(item)
+	}
+
+	return nil
+}
+`,
+		},
+	}
+
+	var buffer bytes.Buffer
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer buffer.Reset()
+			err := tpl.ExecuteTemplate(&buffer, ParamFuncUnmarshalArrayScalarName, test.args)
+			if test.wantErr && err == nil {
+				t.Error("should have failed")
+			}
+
+			if !reflect.DeepEqual(buffer.String(), test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", buffer.String(), test.result)
+			}
+		})
+	}
+}
+
+func TestParamFuncValidate(t *testing.T) {
+	tpl, err := template.New(ParamFuncValidateName).Parse(ParamFuncValidateTpl)
+	if err != nil {
+		t.Errorf("couldnt create template %s: %s", ParamFuncValidateName, err.Error())
+	}
+
+	tests := []struct {
+		name    string
+		args    ParamFuncArgs
+		wantErr bool
+		result  string
+	}{
+		{
+			"Enum param",
+			ParamFuncArgs{
+				ParamName: "env",
+				ParamType: "Env",
+				Enum:      []string{"staging", "prod"},
+			},
+			false,
+			`func (param *Env) Validate(value string) error {
+	switch value {
+	case "staging", "prod":
+	default:
+		return fmt.Errorf("parameter env must be one of %q, %q, got %q", "staging", "prod", value)
+	}
+
+	return nil
+}
+`,
+		},
+		{
+			"Pattern param",
+			ParamFuncArgs{
+				ParamName: "tag",
+				ParamType: "Tag",
+				Pattern:   `^v\d+$`,
+			},
+			false,
+			`func (param *Tag) Validate(value string) error {
+	if !regexp.MustCompile("^v\\d+$").MatchString(value) {
+		return fmt.Errorf("parameter tag must match %s, got %q", "^v\\d+$", value)
+	}
+
+	return nil
+}
+`,
+		},
+		{
+			"Enum and pattern param",
+			ParamFuncArgs{
+				ParamName: "timeout",
+				ParamType: "Timeout",
+				Scalar:    NewScalarArgs("time.Duration"),
+				Enum:      []string{"5m", "10m"},
+				Pattern:   `^\d+m$`,
+			},
+			false,
+			`func (param *Timeout) Validate(value string) error {
+	switch value {
+	case "5m", "10m":
+	default:
+		return fmt.Errorf("parameter timeout must be one of %q, %q, got %q", "5m", "10m", value)
+	}
+
+	if !regexp.MustCompile("^\\d+m$").MatchString(value) {
+		return fmt.Errorf("parameter timeout must match %s, got %q", "^\\d+m$", value)
+	}
+
+	return nil
+}
+`,
+		},
+		{
+			"Scalar enum param",
+			ParamFuncArgs{
+				ParamName: "replicas",
+				ParamType: "Replicas",
+				Scalar:    NewScalarArgs("int"),
+				Enum:      []string{"1", "3"},
+			},
+			false,
+			`func (param *Replicas) Validate(value string) error {
+	switch value {
+	case "1", "3":
+	default:
+		return fmt.Errorf("parameter replicas must be one of %q, %q, got %q", "1", "3", value)
+	}
+
+	return nil
+}
+`,
+		},
+	}
+
+	var buffer bytes.Buffer
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer buffer.Reset()
+			err := tpl.ExecuteTemplate(&buffer, ParamFuncValidateName, test.args)
+			if test.wantErr && err == nil {
+				t.Error("should have failed")
+			}
+
+			if !reflect.DeepEqual(buffer.String(), test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", buffer.String(), test.result)
+			}
+		})
+	}
+}
+
+func TestParamFuncUnmarshalProperties(t *testing.T) {
+	tpl, err := template.New(ParamFuncUnmarshalPropertiesName).Parse(ParamFuncUnmarshalPropertiesTpl)
+	if err != nil {
+		t.Errorf("couldnt create template %s: %s", ParamFuncUnmarshalPropertiesName, err.Error())
+	}
+
+	tests := []struct {
+		name    string
+		args    ParamFuncArgs
+		wantErr bool
+		result  string
+	}{
+		{
+			"Required and optional properties",
+			ParamFuncArgs{
+				ParamName: "git",
+				ParamType: "Git",
+				Properties: []PropertyArgs{
+					{
+						Name:     "revision",
+						Field:    "Revision",
+						EnvVar:   "PARAM_GIT_REVISION_VALUE",
+						Required: false,
+					},
+					{
+						Name:     "url",
+						Field:    "URL",
+						EnvVar:   "PARAM_GIT_URL_VALUE",
+						Required: true,
+					},
+				},
+			},
+			false,
+			`func (param *Git) UnmarshalProperties(lookup func(envVar string) (string, bool)) error {
+	if value, ok := lookup("PARAM_GIT_REVISION_VALUE"); ok {
+		param.Revision = value
+	}
+	if value, ok := lookup("PARAM_GIT_URL_VALUE"); ok {
+		param.URL = value
+	} else {
+		return errors.New("property url of parameter git is not in environment (PARAM_GIT_URL_VALUE is missing)")
+	}
+
+	return nil
+}
+`,
+		},
+	}
+
+	var buffer bytes.Buffer
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer buffer.Reset()
+			err := tpl.ExecuteTemplate(&buffer, ParamFuncUnmarshalPropertiesName, test.args)
+			if test.wantErr && err == nil {
+				t.Error("should have failed")
+			}
+
+			if !reflect.DeepEqual(buffer.String(), test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", buffer.String(), test.result)
+			}
+		})
+	}
+}
+
+func TestParamFuncPropertyEnvVars(t *testing.T) {
+	tpl, err := template.New(ParamFuncPropertyEnvVarsName).Parse(ParamFuncPropertyEnvVarsTpl)
+	if err != nil {
+		t.Errorf("couldnt create template %s: %s", ParamFuncPropertyEnvVarsName, err.Error())
+	}
+
+	tests := []struct {
+		name    string
+		args    ParamFuncArgs
+		wantErr bool
+		result  string
+	}{
+		{
+			"Object param",
+			ParamFuncArgs{
+				ParamName: "git",
+				ParamType: "Git",
+				Properties: []PropertyArgs{
+					{
+						Name:   "url",
+						Field:  "URL",
+						EnvVar: "PARAM_GIT_URL_VALUE",
+					},
+				},
+			},
+			false,
+			`func (param *Git) PropertyEnvVars() map[string]string {
+	return map[string]string{
+		"url": "PARAM_GIT_URL_VALUE",
+	}
+}
+`,
+		},
+	}
+
+	var buffer bytes.Buffer
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer buffer.Reset()
+			err := tpl.ExecuteTemplate(&buffer, ParamFuncPropertyEnvVarsName, test.args)
+			if test.wantErr && err == nil {
+				t.Error("should have failed")
+			}
+
+			if !reflect.DeepEqual(buffer.String(), test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", buffer.String(), test.result)
+			}
+		})
+	}
+}
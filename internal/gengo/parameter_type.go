@@ -18,6 +18,12 @@ package gengo
 
 const ParameterTypeName = "parameter.type"
 
+// ParameterTypeArgs are the arguments of the ParameterTypeTpl template
+type ParameterTypeArgs struct {
+	ArrayArgBegin string
+	ArrayArgEnd   string
+}
+
 const ParameterTypeTpl = `// Parameter is capable of unmarshaling a specific Tekton parameter
 type Parameter interface {
 	// Unmarshal must result in the receiver being populated
@@ -27,23 +33,101 @@ type Parameter interface {
 	// Name is the name of the parameter as it appears in your Task
 	// manifest
 	Name() string
+
+	// EnvVar is the name of the environment variable holding the
+	// value of the parameter
+	EnvVar() string
+}
+
+// ArrayParameter is a Parameter of type array, Tekton can only
+// expand arrays in the arguments of a step, so they are read from
+// the command-line arguments instead of the environment
+type ArrayParameter interface {
+	Parameter
+
+	// UnmarshalArray must result in the receiver being populated
+	// from the passed elements
+	UnmarshalArray([]string) error
+}
+
+// ObjectParameter is a Parameter of type object, each of its
+// properties is read from its own environment variable
+type ObjectParameter interface {
+	Parameter
+
+	// UnmarshalProperties must result in the receiver being populated
+	// from the environment variables returned by lookup
+	UnmarshalProperties(lookup func(envVar string) (string, bool)) error
+
+	// PropertyEnvVars maps each property to the environment variable
+	// holding its value
+	PropertyEnvVars() map[string]string
+}
+
+// ValidatedParameter is a Parameter whose values are restricted,
+// Read returns the error of Validate before the parameter is unmarshaled
+type ValidatedParameter interface {
+	Parameter
+
+	// Validate returns an error naming the parameter when the value
+	// read from the environment is not allowed
+	Validate(value string) error
 }
 
 // Read a parameter from environment variable or returns an error
 func Read(v Parameter) error {
-	envVarName := "PARAM_" + strings.ToUpper(v.Name()) + "_VALUE"
+	return ReadFrom(OSEnv{}, v)
+}
 
-	envVarValue, ok := os.LookupEnv(envVarName)
+// ReadFrom is like Read but reads the parameter from the given Env
+func ReadFrom(env Env, v Parameter) error {
+	if array, isArray := v.(ArrayParameter); isArray {
+		return readArgs(array, env.Args())
+	}
+
+	if object, isObject := v.(ObjectParameter); isObject {
+		return object.UnmarshalProperties(env.LookupEnv)
+	}
+
+	envVarName := v.EnvVar()
+
+	envVarValue, ok := env.LookupEnv(envVarName)
 	if !ok {
 		return errors.New(fmt.Sprintf("parameter %s is not in environment (%s is missing)", v.Name(), envVarName))
 	}
 
-	err := v.Unmarshal([]byte(envVarValue))
-	if err != nil {
-		return err
+	if validated, isValidated := v.(ValidatedParameter); isValidated {
+		err := validated.Validate(envVarValue)
+		if err != nil {
+			return err
+		}
+	}
+
+	return v.Unmarshal([]byte(envVarValue))
+}
+
+// readArgs reads an array parameter from the arguments delimited by
+// {{.ArrayArgBegin}}<name> and {{.ArrayArgEnd}}<name>
+func readArgs(v ArrayParameter, args []string) error {
+	begin := "{{.ArrayArgBegin}}" + v.Name()
+	end := "{{.ArrayArgEnd}}" + v.Name()
+
+	for i, arg := range args {
+		if arg != begin {
+			continue
+		}
+
+		values := args[i+1:]
+		for j, value := range values {
+			if value == end {
+				return v.UnmarshalArray(values[:j])
+			}
+		}
+
+		return errors.New(fmt.Sprintf("parameter %s is not terminated in arguments (%s is missing)", v.Name(), end))
 	}
 
-	return nil
+	return errors.New(fmt.Sprintf("parameter %s is not in arguments (%s is missing)", v.Name(), begin))
 }
 
 // MustRead is like Read but will panic if it fails
@@ -0,0 +1,384 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gengo
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/raskyld/go-tektasker/internal/ir"
+	"golang.org/x/tools/go/packages"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+)
+
+// runGenerated builds the generated env.go, parameter.go and result.go in a main package along with the
+// declarations, importing importPaths, and the generated source files, each in its own file,
+// and returns the lines printed by its main function
+func runGenerated(t *testing.T, importPaths []string, decls string, generated ...string) []string {
+	t.Helper()
+
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is needed to build the generated code")
+	}
+
+	tpl := template.New("")
+	for name, text := range map[string]string{
+		GoHeaderName:      GoHeaderTpl,
+		EnvTypeName:       EnvTypeTpl,
+		ParameterTypeName: ParameterTypeTpl,
+		ResultTypeName:    ResultTypeTpl,
+	} {
+		if _, err := tpl.New(name).Parse(text); err != nil {
+			t.Fatalf("couldnt create template %s: %s", name, err.Error())
+		}
+	}
+
+	var internal, main bytes.Buffer
+	err = tpl.ExecuteTemplate(&internal, GoHeaderName, GoHeaderArgs{
+		PkgName:     "main",
+		ImportPaths: []string{"errors", "fmt", "os", "sync"},
+	})
+	if err == nil {
+		err = tpl.ExecuteTemplate(&internal, EnvTypeName, nil)
+	}
+	if err == nil {
+		err = tpl.ExecuteTemplate(&internal, ParameterTypeName, ParameterTypeArgs{
+			ArrayArgBegin: "--begin-",
+			ArrayArgEnd:   "--end-",
+		})
+	}
+	if err == nil {
+		err = tpl.ExecuteTemplate(&internal, ResultTypeName, nil)
+	}
+	if err == nil {
+		err = tpl.ExecuteTemplate(&main, GoHeaderName, GoHeaderArgs{
+			PkgName:     "main",
+			ImportPaths: importPaths,
+		})
+	}
+	if err != nil {
+		t.Fatalf("couldnt generate the code: %s", err.Error())
+	}
+
+	main.WriteString(decls)
+
+	files := map[string][]byte{
+		"go.mod":       []byte("module generated\n\ngo 1.21\n"),
+		"tektasker.go": internal.Bytes(),
+		"main.go":      main.Bytes(),
+	}
+	for i, source := range generated {
+		files[fmt.Sprintf("zz_generated_%d.go", i)] = []byte(source)
+	}
+
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), content, 0666); err != nil {
+			t.Fatalf("couldnt write %s: %s", name, err.Error())
+		}
+	}
+
+	cmd := exec.Command(goBin, "run", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOWORK=off", "GOFLAGS=")
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("couldnt run the generated code: %s\n%s", err.Error(), output)
+	}
+
+	return strings.Split(strings.TrimSuffix(string(output), "\n"), "\n")
+}
+
+func TestReadArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   []string
+		result string
+	}{
+		{
+			"Array between other arguments",
+			[]string{"--verbose", "--begin-tags", "a", "b", "--end-tags", "run"},
+			`["a" "b"] <nil>`,
+		},
+		{
+			"Empty array",
+			[]string{"--begin-tags", "--end-tags"},
+			`[] <nil>`,
+		},
+		{
+			"Array of another parameter",
+			[]string{"--begin-other", "a", "--end-other"},
+			`[] parameter tags is not in arguments (--begin-tags is missing)`,
+		},
+		{
+			"Missing begin marker",
+			[]string{"a", "b", "--end-tags"},
+			`[] parameter tags is not in arguments (--begin-tags is missing)`,
+		},
+		{
+			"Missing end marker",
+			[]string{"--begin-tags", "a", "b"},
+			`[] parameter tags is not terminated in arguments (--end-tags is missing)`,
+		},
+	}
+
+	var cases strings.Builder
+	for _, test := range tests {
+		fmt.Fprintf(&cases, "\t\t%#v,\n", test.args)
+	}
+
+	results := runGenerated(t, []string{"errors", "fmt"}, `
+type Tags []string
+
+func (*Tags) Unmarshal([]byte) error {
+	return errors.New("tags must be read from the arguments")
+}
+
+func (*Tags) Name() string {
+	return "tags"
+}
+
+func (*Tags) EnvVar() string {
+	return ""
+}
+
+func (tags *Tags) UnmarshalArray(values []string) error {
+	*tags = values
+	return nil
+}
+
+func main() {
+	for _, args := range [][]string{
+`+cases.String()+`	} {
+		env := NewMemoryEnv()
+		env.Arguments = args
+
+		tags := Tags{}
+		err := ReadFrom(env, &tags)
+		fmt.Printf("%q %v\n", []string(tags), err)
+	}
+}
+`)
+
+	if len(results) != len(tests) {
+		t.Fatalf("unwanted diff, got\n---\n%s\n---\nwanted %d results", results, len(tests))
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !reflect.DeepEqual(results[i], test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", results[i], test.result)
+			}
+		})
+	}
+}
+
+func TestReadFromValidated(t *testing.T) {
+	params := []struct {
+		typeName string
+		goType   string
+		printed  string
+		args     ParamFuncArgs
+	}{
+		{
+			"Timeout",
+			"time.Duration",
+			"time.Duration(param)",
+			ParamFuncArgs{
+				ParamName: "timeout",
+				EnvVar:    "PARAM_TIMEOUT_VALUE",
+				Scalar:    NewScalarArgs("time.Duration"),
+				Enum:      []string{"5m", "1h30m", "1h30s"},
+				Pattern:   `^[0-9hm]+$`,
+			},
+		},
+		{
+			"Count",
+			"int",
+			"param",
+			ParamFuncArgs{
+				ParamName: "count",
+				EnvVar:    "PARAM_COUNT_VALUE",
+				Scalar:    NewScalarArgs("int"),
+				Enum:      []string{"007", "8"},
+			},
+		},
+		{
+			"Debug",
+			"bool",
+			"param",
+			ParamFuncArgs{
+				ParamName: "debug",
+				EnvVar:    "PARAM_DEBUG_VALUE",
+				Scalar:    NewScalarArgs("bool"),
+				Pattern:   `^(True|False)$`,
+			},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		param  string
+		value  string
+		result string
+	}{
+		{"Duration in the enum", "Timeout", "5m", `5m0s <nil>`},
+		{"Duration formatted differently", "Timeout", "5m0s", `0s parameter timeout must be one of "5m", "1h30m", "1h30s", got "5m0s"`},
+		{"Duration in the enum not matching the pattern", "Timeout", "1h30s", `0s parameter timeout must match ^[0-9hm]+$, got "1h30s"`},
+		{"Integer with leading zeros", "Count", "007", `7 <nil>`},
+		{"Integer without leading zeros", "Count", "7", `0 parameter count must be one of "007", "8", got "7"`},
+		{"Boolean matching the pattern", "Debug", "True", `true <nil>`},
+		{"Boolean not matching the pattern", "Debug", "true", `false parameter debug must match ^(True|False)$, got "true"`},
+	}
+
+	tpl := template.New("")
+	for name, text := range map[string]string{
+		ParamFuncNameName:            ParamFuncNameTpl,
+		ParamFuncEnvVarName:          ParamFuncEnvVarTpl,
+		ParamFuncUnmarshalScalarName: ParamFuncUnmarshalScalarTpl,
+		ParamFuncValidateName:        ParamFuncValidateTpl,
+	} {
+		if _, err := tpl.New(name).Parse(text); err != nil {
+			t.Fatalf("couldnt create template %s: %s", name, err.Error())
+		}
+	}
+
+	var decls bytes.Buffer
+	printed := make(map[string]string, len(params))
+	for _, param := range params {
+		printed[param.typeName] = param.printed
+		param.args.ParamType = param.typeName
+		fmt.Fprintf(&decls, "\ntype %s %s\n\n", param.typeName, param.goType)
+
+		for _, name := range []string{ParamFuncNameName, ParamFuncEnvVarName, ParamFuncUnmarshalScalarName, ParamFuncValidateName} {
+			if err := tpl.ExecuteTemplate(&decls, name, param.args); err != nil {
+				t.Fatalf("couldnt execute template %s: %s", name, err.Error())
+			}
+		}
+	}
+
+	decls.WriteString("\nfunc main() {\n")
+	for _, test := range tests {
+		fmt.Fprintf(&decls, "\t{\n\t\tvar param %s\n\t\tenv := NewMemoryEnv()\n\t\tenv.Vars[param.EnvVar()] = %q\n", test.param, test.value)
+		fmt.Fprintf(&decls, "\t\terr := ReadFrom(env, &param)\n\t\tfmt.Printf(\"%%v %%v\\n\", %s, err)\n\t}\n", printed[test.param])
+	}
+	decls.WriteString("}\n")
+
+	results := runGenerated(t, []string{"fmt", "regexp", "strconv", "time"}, decls.String())
+	if len(results) != len(tests) {
+		t.Fatalf("unwanted diff, got\n---\n%s\n---\nwanted %d results", results, len(tests))
+	}
+
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if !reflect.DeepEqual(results[i], test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", results[i], test.result)
+			}
+		})
+	}
+}
+
+func TestReadFromProperties(t *testing.T) {
+	task := &ir.Task{
+		Name:    "clone",
+		Package: &loader.Package{Package: &packages.Package{Name: "main"}},
+		Params: []*ir.Param{
+			{
+				Name:     "repo",
+				GoType:   "Repo",
+				Type:     ir.TypeObject,
+				Encoding: ir.EncodingJSON,
+				Properties: []*ir.Property{
+					{Name: "revision", Field: "Revision", EnvVar: "PARAM_REPO_REVISION_VALUE"},
+					{Name: "url", Field: "URL", EnvVar: "PARAM_REPO_URL_VALUE"},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		required bool
+		env      map[string]string
+		result   string
+	}{
+		{
+			"Every optional property",
+			false,
+			map[string]string{"PARAM_REPO_REVISION_VALUE": "main", "PARAM_REPO_URL_VALUE": "https://example.com"},
+			`{URL:https://example.com Revision:main} <nil>`,
+		},
+		{
+			"Missing optional property",
+			false,
+			map[string]string{"PARAM_REPO_URL_VALUE": "https://example.com"},
+			`{URL:https://example.com Revision:} <nil>`,
+		},
+		{
+			"Missing required property",
+			true,
+			map[string]string{"PARAM_REPO_REVISION_VALUE": "main"},
+			`{URL: Revision:main} property url of parameter repo is not in environment (PARAM_REPO_URL_VALUE is missing)`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			task.Params[0].Properties[1].Required = test.required
+
+			g, err := NewGoFunc(slog.New(slog.NewTextHandler(io.Discard, nil)), nil, "", "")
+			if err != nil {
+				t.Fatalf("couldnt create the generator: %s", err.Error())
+			}
+
+			var generated bytes.Buffer
+			if err := g.Template.ExecuteTemplate(&generated, FuncName, g.funcArgs(task, "")); err != nil {
+				t.Fatalf("couldnt generate the code: %s", err.Error())
+			}
+
+			results := runGenerated(t, []string{"fmt"}, fmt.Sprintf(`
+type Repo struct {
+	URL      string `+"`json:\"url\"`"+`
+	Revision string `+"`json:\"revision,omitempty\"`"+`
+}
+
+func main() {
+	var param Repo
+	env := NewMemoryEnv()
+	env.Vars = %#v
+	err := ReadFrom(env, &param)
+	fmt.Printf("%%+v %%v\n", param, err)
+}
+`, test.env), generated.String())
+
+			if !reflect.DeepEqual(results, []string{test.result}) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", results, test.result)
+			}
+		})
+	}
+}
@@ -23,6 +23,13 @@ const ResultFuncNameTpl = `func (result *{{.ResultType}}) Name() string {
 }
 `
 
+const ResultFuncEnvVarName = "result.func.envvar"
+
+const ResultFuncEnvVarTpl = `func (result *{{.ResultType}}) EnvVar() string {
+	return "{{.EnvVar}}"
+}
+`
+
 const ResultFuncMarshalSimpleName = "result.func.marshal.simple"
 
 const ResultFuncMarshalSimpleTpl = `func (result *{{.ResultType}}) Marshal() ([]byte, error) {
@@ -30,6 +37,13 @@ const ResultFuncMarshalSimpleTpl = `func (result *{{.ResultType}}) Marshal() ([]
 }
 `
 
+const ResultFuncMarshalScalarName = "result.func.marshal.scalar"
+
+const ResultFuncMarshalScalarTpl = `func (result *{{.ResultType}}) Marshal() ([]byte, error) {
+	return []byte({{printf .Scalar.Format "*result"}}), nil
+}
+`
+
 const ResultFuncMarshalJSONName = "result.func.marshal.json"
 
 const ResultFuncMarshalJSONTpl = `func (result *{{.ResultType}}) Marshal() ([]byte, error) {
@@ -37,7 +51,53 @@ const ResultFuncMarshalJSONTpl = `func (result *{{.ResultType}}) Marshal() ([]by
 }
 `
 
+const ResultFuncMarshalPropertiesName = "result.func.marshal.properties"
+
+// ResultFuncMarshalPropertiesTpl writes every property, even empty ones,
+// as Tekton rejects object results with missing keys
+const ResultFuncMarshalPropertiesTpl = `func (result *{{.ResultType}}) Marshal() ([]byte, error) {
+	return json.Marshal(map[string]string{
+{{- range .Properties}}
+		"{{.Name}}": result.{{.Field}},
+{{- end}}
+	})
+}
+`
+
+const ResultFuncUnmarshalSimpleName = "result.func.unmarshal.simple"
+
+const ResultFuncUnmarshalSimpleTpl = `func (result *{{.ResultType}}) Unmarshal(buf []byte) error {
+	*result = {{.ResultType}}(buf)
+	return nil
+}
+`
+
+const ResultFuncUnmarshalScalarName = "result.func.unmarshal.scalar"
+
+const ResultFuncUnmarshalScalarTpl = `func (result *{{.ResultType}}) Unmarshal(buf []byte) error {
+	value, err := {{printf .Scalar.Parse "string(buf)"}}
+	if err != nil {
+		return err
+	}
+
+	*result = {{.ResultType}}(value)
+	return nil
+}
+`
+
+const ResultFuncUnmarshalJSONName = "result.func.unmarshal.json"
+
+const ResultFuncUnmarshalJSONTpl = `func (result *{{.ResultType}}) Unmarshal(buf []byte) error {
+	return json.Unmarshal(buf, result)
+}
+`
+
 type ResultFuncArgs struct {
 	ResultName string
 	ResultType string
+	EnvVar     string
+	Properties []PropertyArgs
+
+	// Scalar is set for the results made of a builtin scalar
+	Scalar *ScalarArgs
 }
@@ -65,6 +65,49 @@ func TestResultFuncName(t *testing.T) {
 	}
 }
 
+func TestResultFuncEnvVar(t *testing.T) {
+	tpl, err := template.New(ResultFuncEnvVarName).Parse(ResultFuncEnvVarTpl)
+	if err != nil {
+		t.Errorf("couldnt create template %s: %s", ResultFuncEnvVarName, err.Error())
+	}
+
+	tests := []struct {
+		name    string
+		args    ResultFuncArgs
+		wantErr bool
+		result  string
+	}{
+		{
+			"Sanitised env var",
+			ResultFuncArgs{
+				ResultName: "commit.sha",
+				ResultType: "CommitSHA",
+				EnvVar:     "RESULT_COMMIT_SHA_PATH",
+			},
+			false,
+			`func (result *CommitSHA) EnvVar() string {
+	return "RESULT_COMMIT_SHA_PATH"
+}
+`,
+		},
+	}
+
+	var buffer bytes.Buffer
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer buffer.Reset()
+			err := tpl.ExecuteTemplate(&buffer, ResultFuncEnvVarName, test.args)
+			if test.wantErr && err == nil {
+				t.Error("should have failed")
+			}
+
+			if !reflect.DeepEqual(buffer.String(), test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", buffer.String(), test.result)
+			}
+		})
+	}
+}
+
 func TestResultFuncMarshalSimple(t *testing.T) {
 	tpl, err := template.New(ResultFuncMarshalSimpleName).Parse(ResultFuncMarshalSimpleTpl)
 	if err != nil {
@@ -148,3 +191,261 @@ func TestResultFuncMarshalJSON(t *testing.T) {
 		})
 	}
 }
+
+func TestResultFuncMarshalProperties(t *testing.T) {
+	tpl, err := template.New(ResultFuncMarshalPropertiesName).Parse(ResultFuncMarshalPropertiesTpl)
+	if err != nil {
+		t.Errorf("couldnt create template %s: %s", ResultFuncMarshalPropertiesName, err.Error())
+	}
+
+	tests := []struct {
+		name    string
+		args    ResultFuncArgs
+		wantErr bool
+		result  string
+	}{
+		{
+			"Object result",
+			ResultFuncArgs{
+				ResultName: "image",
+				ResultType: "Image",
+				Properties: []PropertyArgs{
+					{
+						Name:  "digest",
+						Field: "Digest",
+					},
+					{
+						Name:  "url",
+						Field: "URL",
+					},
+				},
+			},
+			false,
+			`func (result *Image) Marshal() ([]byte, error) {
+	return json.Marshal(map[string]string{
+		"digest": result.Digest,
+		"url": result.URL,
+	})
+}
+`,
+		},
+	}
+
+	var buffer bytes.Buffer
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer buffer.Reset()
+			err := tpl.ExecuteTemplate(&buffer, ResultFuncMarshalPropertiesName, test.args)
+			if test.wantErr && err == nil {
+				t.Error("should have failed")
+			}
+
+			if !reflect.DeepEqual(buffer.String(), test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", buffer.String(), test.result)
+			}
+		})
+	}
+}
+
+func TestResultFuncUnmarshalSimple(t *testing.T) {
+	tpl, err := template.New(ResultFuncUnmarshalSimpleName).Parse(ResultFuncUnmarshalSimpleTpl)
+	if err != nil {
+		t.Errorf("couldnt create template %s: %s", ResultFuncUnmarshalSimpleName, err.Error())
+	}
+
+	tests := []struct {
+		name    string
+		args    ResultFuncArgs
+		wantErr bool
+		result  string
+	}{
+		{
+			"Simple result",
+			ResultFuncArgs{
+				ResultName: "result1",
+				ResultType: "ResultOne",
+			},
+			false,
+			`func (result *ResultOne) Unmarshal(buf []byte) error {
+	*result = ResultOne(buf)
+	return nil
+}
+`,
+		},
+	}
+
+	var buffer bytes.Buffer
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer buffer.Reset()
+			err := tpl.ExecuteTemplate(&buffer, ResultFuncUnmarshalSimpleName, test.args)
+			if test.wantErr && err == nil {
+				t.Error("should have failed")
+			}
+
+			if !reflect.DeepEqual(buffer.String(), test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", buffer.String(), test.result)
+			}
+		})
+	}
+}
+
+func TestResultFuncUnmarshalJSON(t *testing.T) {
+	tpl, err := template.New(ResultFuncUnmarshalJSONName).Parse(ResultFuncUnmarshalJSONTpl)
+	if err != nil {
+		t.Errorf("couldnt create template %s: %s", ResultFuncUnmarshalJSONName, err.Error())
+	}
+
+	tests := []struct {
+		name    string
+		args    ResultFuncArgs
+		wantErr bool
+		result  string
+	}{
+		{
+			"JSON result",
+			ResultFuncArgs{
+				ResultName: "result1",
+				ResultType: "ResultOne",
+			},
+			false,
+			`func (result *ResultOne) Unmarshal(buf []byte) error {
+	return json.Unmarshal(buf, result)
+}
+`,
+		},
+	}
+
+	var buffer bytes.Buffer
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer buffer.Reset()
+			err := tpl.ExecuteTemplate(&buffer, ResultFuncUnmarshalJSONName, test.args)
+			if test.wantErr && err == nil {
+				t.Error("should have failed")
+			}
+
+			if !reflect.DeepEqual(buffer.String(), test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", buffer.String(), test.result)
+			}
+		})
+	}
+}
+
+func TestResultFuncMarshalScalar(t *testing.T) {
+	tpl, err := template.New(ResultFuncMarshalScalarName).Parse(ResultFuncMarshalScalarTpl)
+	if err != nil {
+		t.Errorf("couldnt create template %s: %s", ResultFuncMarshalScalarName, err.Error())
+	}
+
+	tests := []struct {
+		name    string
+		args    ResultFuncArgs
+		wantErr bool
+		result  string
+	}{
+		{
+			"Int64 result",
+			ResultFuncArgs{
+				ResultName: "size",
+				ResultType: "Size",
+				Scalar:     NewScalarArgs("int64"),
+			},
+			false,
+			`func (result *Size) Marshal() ([]byte, error) {
+	return []byte(strconv.FormatInt(int64(*result), 10)), nil
+}
+`,
+		},
+		{
+			"Float64 result",
+			ResultFuncArgs{
+				ResultName: "coverage",
+				ResultType: "Coverage",
+				Scalar:     NewScalarArgs("float64"),
+			},
+			false,
+			`func (result *Coverage) Marshal() ([]byte, error) {
+	return []byte(strconv.FormatFloat(float64(*result), 'g', -1, 64)), nil
+}
+`,
+		},
+		{
+			"Duration result",
+			ResultFuncArgs{
+				ResultName: "elapsed",
+				ResultType: "Elapsed",
+				Scalar:     NewScalarArgs("time.Duration"),
+			},
+			false,
+			`func (result *Elapsed) Marshal() ([]byte, error) {
+	return []byte(time.Duration(*result).String()), nil
+}
+`,
+		},
+	}
+
+	var buffer bytes.Buffer
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer buffer.Reset()
+			err := tpl.ExecuteTemplate(&buffer, ResultFuncMarshalScalarName, test.args)
+			if test.wantErr && err == nil {
+				t.Error("should have failed")
+			}
+
+			if !reflect.DeepEqual(buffer.String(), test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", buffer.String(), test.result)
+			}
+		})
+	}
+}
+
+func TestResultFuncUnmarshalScalar(t *testing.T) {
+	tpl, err := template.New(ResultFuncUnmarshalScalarName).Parse(ResultFuncUnmarshalScalarTpl)
+	if err != nil {
+		t.Errorf("couldnt create template %s: %s", ResultFuncUnmarshalScalarName, err.Error())
+	}
+
+	tests := []struct {
+		name    string
+		args    ResultFuncArgs
+		wantErr bool
+		result  string
+	}{
+		{
+			"Bool result",
+			ResultFuncArgs{
+				ResultName: "changed",
+				ResultType: "Changed",
+				Scalar:     NewScalarArgs("bool"),
+			},
+			false,
+			`func (result *Changed) Unmarshal(buf []byte) error {
+	value, err := strconv.ParseBool(string(buf))
+	if err != nil {
+		return err
+	}
+
+	*result = Changed(value)
+	return nil
+}
+`,
+		},
+	}
+
+	var buffer bytes.Buffer
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer buffer.Reset()
+			err := tpl.ExecuteTemplate(&buffer, ResultFuncUnmarshalScalarName, test.args)
+			if test.wantErr && err == nil {
+				t.Error("should have failed")
+			}
+
+			if !reflect.DeepEqual(buffer.String(), test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", buffer.String(), test.result)
+			}
+		})
+	}
+}
@@ -28,13 +28,26 @@ type Result interface {
 	// Name is the name of your Result as it appears in your Tekton
 	// Task manifest
 	Name() string
+
+	// EnvVar is the name of the environment variable holding the
+	// path of the result file
+	EnvVar() string
+
+	// Unmarshal is the reverse of Marshal, it is used to read
+	// a result back, e.g. in your tests
+	Unmarshal([]byte) error
 }
 
 // Write the Result back to the filesystem for Tekton to consume it
 func Write(r Result) error {
-	envVarName := "RESULT_" + strings.ToUpper(r.Name()) + "_PATH"
+	return WriteTo(OSEnv{}, r)
+}
+
+// WriteTo is like Write but writes the Result to the given Env
+func WriteTo(env Env, r Result) error {
+	envVarName := r.EnvVar()
 
-	resultPath, ok := os.LookupEnv(envVarName)
+	resultPath, ok := env.LookupEnv(envVarName)
 	if !ok {
 		return errors.New(fmt.Sprintf("result %s path could not be loaded (%s is missing)", r.Name(), envVarName))
 	}
@@ -44,7 +57,7 @@ func Write(r Result) error {
 		return err
 	}
 
-	return os.WriteFile(resultPath, resultValue, 0666)
+	return env.WriteFile(resultPath, resultValue)
 }
 
 // MustWrite is like Write but will panic if it fails
@@ -0,0 +1,68 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gengo
+
+import (
+	"fmt"
+
+	"github.com/raskyld/go-tektasker/internal/ir"
+)
+
+// ScalarArgs are the Go expressions parsing and formatting a builtin scalar kind,
+// Parse is a format taking the string to parse and Format a format taking the value
+type ScalarArgs struct {
+	Kind   string
+	Parse  string
+	Format string
+
+	// ImportPath is the package used by the expressions
+	ImportPath string
+}
+
+// NewScalarArgs returns the expressions of a scalar kind, it is nil if the kind is not a scalar
+func NewScalarArgs(kind string) *ScalarArgs {
+	bitSize, ok := ir.ScalarBitSize(kind)
+	if !ok {
+		return nil
+	}
+
+	args := &ScalarArgs{
+		Kind:       kind,
+		ImportPath: "strconv",
+	}
+
+	switch kind {
+	case "bool":
+		args.Parse = "strconv.ParseBool(%s)"
+		args.Format = "strconv.FormatBool(bool(%s))"
+	case "int", "int8", "int16", "int32", "int64", "rune":
+		args.Parse = fmt.Sprintf("strconv.ParseInt(%%s, 10, %d)", bitSize)
+		args.Format = "strconv.FormatInt(int64(%s), 10)"
+	case "uint", "uint8", "uint16", "uint32", "uint64", "byte":
+		args.Parse = fmt.Sprintf("strconv.ParseUint(%%s, 10, %d)", bitSize)
+		args.Format = "strconv.FormatUint(uint64(%s), 10)"
+	case "float32", "float64":
+		args.Parse = fmt.Sprintf("strconv.ParseFloat(%%s, %d)", bitSize)
+		args.Format = fmt.Sprintf("strconv.FormatFloat(float64(%%s), 'g', -1, %d)", bitSize)
+	case ir.ScalarDuration:
+		args.Parse = "time.ParseDuration(%s)"
+		args.Format = "time.Duration(%s).String()"
+		args.ImportPath = "time"
+	}
+
+	return args
+}
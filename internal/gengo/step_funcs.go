@@ -0,0 +1,50 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gengo
+
+const StepFuncDispatchName = "step.func.dispatch"
+
+const StepFuncDispatchTpl = `// Dispatch runs the entrypoint of the step named by the {{.EnvVar}} environment variable.
+// It returns false when the variable is not set so your main function can carry on.
+func Dispatch() (bool, error) {
+	step, ok := os.LookupEnv("{{.EnvVar}}")
+	if !ok {
+		return false, nil
+	}
+
+	switch step {
+	{{- range .Steps}}
+	case "{{.StepName}}":
+		return true, {{.Entrypoint}}()
+	{{- end}}
+	default:
+		return true, fmt.Errorf("unknown step %q", step)
+	}
+}
+`
+
+type StepFuncDispatchArgs struct {
+	// EnvVar is the environment variable holding the name of the step to run
+	EnvVar string
+
+	Steps []StepFuncArgs
+}
+
+type StepFuncArgs struct {
+	StepName   string
+	Entrypoint string
+}
@@ -0,0 +1,89 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gengo
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"text/template"
+)
+
+func TestStepFuncDispatch(t *testing.T) {
+	tpl, err := template.New(StepFuncDispatchName).Parse(StepFuncDispatchTpl)
+	if err != nil {
+		t.Errorf("couldnt create template %s: %s", StepFuncDispatchName, err.Error())
+	}
+
+	tests := []struct {
+		name    string
+		args    StepFuncDispatchArgs
+		wantErr bool
+		result  string
+	}{
+		{
+			"Two steps",
+			StepFuncDispatchArgs{
+				EnvVar: "TEKTASKER_STEP",
+				Steps: []StepFuncArgs{
+					{
+						StepName:   "build",
+						Entrypoint: "Build",
+					},
+					{
+						StepName:   "push",
+						Entrypoint: "Push",
+					},
+				},
+			},
+			false,
+			`// Dispatch runs the entrypoint of the step named by the TEKTASKER_STEP environment variable.
+// It returns false when the variable is not set so your main function can carry on.
+func Dispatch() (bool, error) {
+	step, ok := os.LookupEnv("TEKTASKER_STEP")
+	if !ok {
+		return false, nil
+	}
+
+	switch step {
+	case "build":
+		return true, Build()
+	case "push":
+		return true, Push()
+	default:
+		return true, fmt.Errorf("unknown step %q", step)
+	}
+}
+`,
+		},
+	}
+
+	var buffer bytes.Buffer
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer buffer.Reset()
+			err := tpl.ExecuteTemplate(&buffer, StepFuncDispatchName, test.args)
+			if test.wantErr && err == nil {
+				t.Error("should have failed")
+			}
+
+			if !reflect.DeepEqual(buffer.String(), test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", buffer.String(), test.result)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package gengo
+
+const TektonTestPkgName = "tektontest"
+
+const TektonTestName = "tektontest.type"
+
+const TektonTestTpl = `// Step is the environment of a step under test, the params are set and the
+// results are written to a temporary directory until the end of the test.
+// As it changes the environment of the process, it can not be used in
+// parallel tests.
+type Step struct {
+	t          testing.TB
+	program    string
+	args       []string
+	resultsDir string
+}
+
+// NewStep returns the environment of a step for the duration of the test
+func NewStep(t testing.TB) *Step {
+	t.Helper()
+
+	args := os.Args
+	t.Cleanup(func() {
+		os.Args = args
+	})
+
+	return &Step{
+		t:          t,
+		program:    args[0],
+		resultsDir: t.TempDir(),
+	}
+}
+
+// SetParam sets the value of a param, arrays and objects are given as JSON
+func (s *Step) SetParam(p {{.PkgName}}.Parameter, value string) *Step {
+	s.t.Helper()
+
+	switch param := p.(type) {
+	case {{.PkgName}}.ArrayParameter:
+		var values []string
+		err := json.Unmarshal([]byte(value), &values)
+		if err != nil {
+			s.t.Fatalf("value of array parameter %s must be a JSON array of strings: %s", p.Name(), err)
+		}
+
+		s.args = append(s.args, "{{.ArrayArgBegin}}"+p.Name())
+		s.args = append(s.args, values...)
+		s.args = append(s.args, "{{.ArrayArgEnd}}"+p.Name())
+		os.Args = append([]string{s.program}, s.args...)
+	case {{.PkgName}}.ObjectParameter:
+		var properties map[string]string
+		err := json.Unmarshal([]byte(value), &properties)
+		if err != nil {
+			s.t.Fatalf("value of object parameter %s must be a JSON object of strings: %s", p.Name(), err)
+		}
+
+		envVars := param.PropertyEnvVars()
+		for key, propertyValue := range properties {
+			envVar, ok := envVars[key]
+			if !ok {
+				s.t.Fatalf("parameter %s has no property %s", p.Name(), key)
+			}
+
+			s.t.Setenv(envVar, propertyValue)
+		}
+	default:
+		s.t.Setenv(p.EnvVar(), value)
+	}
+
+	return s
+}
+
+// SetResults points the results to the temporary directory of the step
+func (s *Step) SetResults(results ...{{.PkgName}}.Result) *Step {
+	s.t.Helper()
+
+	for _, result := range results {
+		s.t.Setenv(result.EnvVar(), filepath.Join(s.resultsDir, result.Name()))
+	}
+
+	return s
+}
+
+// Run the logic of the task, a panic of MustRead or MustWrite is returned as an error
+func (s *Step) Run(fn func() error) (err error) {
+	s.t.Helper()
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("step panicked: %v", recovered)
+		}
+	}()
+
+	return fn()
+}
+
+// ReadResult reads back into r the value written by the step
+func (s *Step) ReadResult(r {{.PkgName}}.Result) error {
+	s.t.Helper()
+
+	buf, err := os.ReadFile(filepath.Join(s.resultsDir, r.Name()))
+	if err != nil {
+		return fmt.Errorf("result %s was not written: %w", r.Name(), err)
+	}
+
+	return r.Unmarshal(buf)
+}
+`
+
+// TektonTestArgs are the arguments of the TektonTestTpl template
+type TektonTestArgs struct {
+	// PkgName is the name of the package holding the Parameter and Result types
+	PkgName string
+
+	ArrayArgBegin string
+	ArrayArgEnd   string
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package genyaml
+
+import (
+	"fmt"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const TektonGroup = "tekton.dev"
+
+// APIVersion holds what differs between the Tekton API versions we can emit
+type APIVersion struct {
+	// Version of the tekton.dev API group
+	Version string
+
+	// BaseDir is the kustomize base where the manifests of this version are written
+	BaseDir string
+
+	// ComputeResourcesField is the name of the step field holding the compute resources
+	ComputeResourcesField string
+}
+
+var (
+	V1 = APIVersion{
+		Version:               "v1",
+		BaseDir:               "base",
+		ComputeResourcesField: "computeResources",
+	}
+
+	// V1Beta1 is still needed for clusters running older Tekton Pipelines releases
+	V1Beta1 = APIVersion{
+		Version:               "v1beta1",
+		BaseDir:               "base-v1beta1",
+		ComputeResourcesField: "resources",
+	}
+)
+
+// APIVersions are all the supported API versions
+var APIVersions = []APIVersion{V1, V1Beta1}
+
+// GroupVersion of the API version
+func (v APIVersion) GroupVersion() schema.GroupVersion {
+	return schema.GroupVersion{
+		Group:   TektonGroup,
+		Version: v.Version,
+	}
+}
+
+// LookupAPIVersion returns the supported API version with the given name
+func LookupAPIVersion(version string) (APIVersion, error) {
+	for _, apiVersion := range APIVersions {
+		if apiVersion.Version == version {
+			return apiVersion, nil
+		}
+	}
+
+	return APIVersion{}, fmt.Errorf("unsupported Tekton API version %q", version)
+}
+
+// LookupAPIVersions returns the API versions with the given names in the given order,
+// a version given more than once is only returned once as each one has its own base
+func LookupAPIVersions(versions []string) ([]APIVersion, error) {
+	apiVersions := make([]APIVersion, 0, len(versions))
+	seen := make(map[string]struct{}, len(versions))
+	for _, version := range versions {
+		apiVersion, err := LookupAPIVersion(version)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, duplicate := seen[apiVersion.Version]; duplicate {
+			continue
+		}
+
+		seen[apiVersion.Version] = struct{}{}
+		apiVersions = append(apiVersions, apiVersion)
+	}
+
+	return apiVersions, nil
+}
+
+// DefaultStepActionVersion is the API version of StepAction we emit by default,
+// StepAction is not part of the v1 API
+const DefaultStepActionVersion = "v1beta1"
+
+// StepActionVersions are all the supported API versions of StepAction
+var StepActionVersions = []string{"v1alpha1", DefaultStepActionVersion}
+
+// LookupStepActionVersion returns the group version of the supported StepAction API version
+func LookupStepActionVersion(version string) (schema.GroupVersion, error) {
+	for _, stepActionVersion := range StepActionVersions {
+		if stepActionVersion == version {
+			return schema.GroupVersion{
+				Group:   TektonGroup,
+				Version: version,
+			}, nil
+		}
+	}
+
+	return schema.GroupVersion{}, fmt.Errorf("unsupported StepAction API version %q", version)
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package genyaml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLookupAPIVersions(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions []string
+		wantErr  bool
+		result   []APIVersion
+	}{
+		{
+			"Both versions",
+			[]string{"v1beta1", "v1"},
+			false,
+			[]APIVersion{V1Beta1, V1},
+		},
+		{
+			"Repeated version",
+			[]string{"v1", "v1beta1", "v1"},
+			false,
+			[]APIVersion{V1, V1Beta1},
+		},
+		{
+			"Unsupported version",
+			[]string{"v1", "v2"},
+			true,
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := LookupAPIVersions(test.versions)
+			if (err != nil) != test.wantErr {
+				t.Errorf("LookupAPIVersions() error = %v, wantErr %v", err, test.wantErr)
+				return
+			}
+
+			if !reflect.DeepEqual(result, test.result) {
+				t.Errorf("unwanted diff, got\n---\n%v\n---\nwanted\n---\n%v", result, test.result)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package genyaml
+
+import (
+	"errors"
+	"fmt"
+	"github.com/raskyld/go-tektasker/internal/gendoc"
+	"github.com/raskyld/go-tektasker/internal/ir"
+	"path"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"strings"
+)
+
+// Annotations indexing a Task in the Tekton Catalog and Hub
+const (
+	CatalogMinVersionAnnotation  = "tekton.dev/pipelines.minVersion"
+	CatalogCategoriesAnnotation  = "tekton.dev/categories"
+	CatalogTagsAnnotation        = "tekton.dev/tags"
+	CatalogDisplayNameAnnotation = "tekton.dev/displayName"
+	CatalogPlatformsAnnotation   = "tekton.dev/platforms"
+)
+
+// TaskCatalogGenerator writes the Tasks in the layout of the Tekton Catalog,
+// i.e. task/<name>/<version>/<name>.yaml next to its README.md
+type TaskCatalogGenerator struct {
+	TaskYamlGenerator
+}
+
+func (g TaskCatalogGenerator) Generate(ctx *genall.GenerationContext) error {
+	apiVersion := V1
+	if len(g.APIVersions) > 1 {
+		return errors.New("the catalog layout holds a single Tekton API version")
+	}
+
+	if len(g.APIVersions) == 1 {
+		apiVersion = g.APIVersions[0]
+	}
+
+	for _, pkg := range ctx.Roots {
+		taskIR, err := g.Builder.Build(ctx.Collector, pkg)
+		if errors.Is(err, ir.ErrInvalidTask) {
+			// diagnostics are reported on the package
+			continue
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if taskIR == nil {
+			continue
+		}
+
+		if taskIR.StepAction {
+			g.Logger.Info("skipping stepaction, the catalog layout only holds tasks", "stepaction", taskIR.Name)
+			continue
+		}
+
+		if taskIR.MinPipelinesVersion == "" {
+			g.Logger.Warn("the catalog expects the minimal Tekton Pipelines version of the task, set minPipelinesVersion on the task marker",
+				"task", taskIR.Name)
+		}
+
+		task, err := g.buildTask(taskIR, apiVersion)
+		if err != nil {
+			return err
+		}
+
+		dir := path.Join("task", taskIR.Name, taskIR.Version)
+		err = ctx.WriteYAML(path.Join(dir, taskIR.Name+".yaml"), "", []interface{}{task.Object})
+		if err != nil {
+			return err
+		}
+
+		readme, err := ctx.Open(nil, path.Join(dir, "README.md"))
+		if err != nil {
+			return err
+		}
+
+		err = gendoc.Render(readme, taskIR, path.Join(dir, taskIR.Name+".yaml"), apiVersion.GroupVersion())
+		if closeErr := readme.Close(); err == nil {
+			err = closeErr
+		}
+
+		if err != nil {
+			return fmt.Errorf("unable to write the README of task %s: %w", taskIR.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// catalogAnnotations are the catalog annotations set by the task marker
+func catalogAnnotations(taskIR *ir.Task) map[string]string {
+	annotations := make(map[string]string)
+
+	if taskIR.MinPipelinesVersion != "" {
+		annotations[CatalogMinVersionAnnotation] = taskIR.MinPipelinesVersion
+	}
+
+	if len(taskIR.Categories) > 0 {
+		annotations[CatalogCategoriesAnnotation] = strings.Join(taskIR.Categories, ", ")
+	}
+
+	if len(taskIR.Tags) > 0 {
+		annotations[CatalogTagsAnnotation] = strings.Join(taskIR.Tags, ", ")
+	}
+
+	if taskIR.DisplayName != "" {
+		annotations[CatalogDisplayNameAnnotation] = taskIR.DisplayName
+	}
+
+	if len(taskIR.Platforms) > 0 {
+		annotations[CatalogPlatformsAnnotation] = strings.Join(taskIR.Platforms, ",")
+	}
+
+	return annotations
+}
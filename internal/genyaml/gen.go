@@ -17,46 +17,50 @@ limitations under the License.
 package genyaml
 
 import (
-	"bytes"
-	"encoding/json"
 	"errors"
 	"fmt"
+	"github.com/raskyld/go-tektasker/internal/ir"
+	"github.com/raskyld/go-tektasker/internal/sample"
 	ttmarkers "github.com/raskyld/go-tektasker/pkg/markers"
-	"go/ast"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	"log/slog"
 	"path"
-	"path/filepath"
 	"sigs.k8s.io/controller-tools/pkg/genall"
 	"sigs.k8s.io/controller-tools/pkg/loader"
 	"sigs.k8s.io/controller-tools/pkg/markers"
 	"strconv"
-	"strings"
-	"text/template"
 )
 
 const KubernetesVersionLabel = "app.kubernetes.io/version"
 
+// ManifestFiles are the patterns of the names of the files TaskYamlGenerator writes in
+// a kustomize base, the kustomization.yaml is left out as it may be written by hand
+var ManifestFiles = []string{"*-task.yaml", "*-stepaction.yaml", "*-pipeline.yaml", "*-sample-taskrun.yaml"}
+
 type TaskYamlGenerator struct {
 	Logger *slog.Logger
 
+	// Builder builds the intermediate representation of the tasks
+	Builder *ir.Builder
+
+	// APIVersions are the Tekton API versions to emit, defaults to V1
+	APIVersions []APIVersion
+
 	// StepCommand is used to fix the command field of the step (i.e. the entrypoint of your container)
+	// when the image is built with ko and the Task does not set its command
 	StepCommand string
-}
 
-// stepCommandArgs is passed when templating the value of StepCommand
-type stepCommandArgs struct {
-	// PkgName is the name of the package being parsed
-	PkgName string
+	// Image is the strategy used for the Tasks without image marker, defaults to KoImage
+	Image ImageStrategy
 
-	// ImportPath of the package
-	ImportPath string
+	// KoResolver names the binaries built by ko, defaults to BaseKoResolver
+	KoResolver KoEntrypointResolver
 
-	// TODO(raskyld): Fragile code! We should use a custom type capable of replicating the behavior of various ko version
-	// KoAppName is replicating the way ko builder (0.15) determinate the entrypoint of the built container
-	// See issue #15 for details
-	KoAppName string
+	// SamplePipeline also writes a sample Pipeline next to the sample TaskRun of every Task
+	SamplePipeline bool
+
+	// StepActionVersion is the API version of the StepAction manifests, defaults to DefaultStepActionVersion
+	StepActionVersion string
 }
 
 func (TaskYamlGenerator) RegisterMarkers(into *markers.Registry) error {
@@ -64,388 +68,495 @@ func (TaskYamlGenerator) RegisterMarkers(into *markers.Registry) error {
 }
 
 func (g TaskYamlGenerator) Generate(ctx *genall.GenerationContext) error {
-	for _, pkg := range ctx.Roots {
-		// NB(raskyld): in the future we may/should use an IR to avoid hard coupling
-		// between the generation process and the specific v1 version
-		logger := g.Logger.With("pkg", pkg.Name)
-		logger.Debug("starting collecting")
+	apiVersions := g.APIVersions
+	if len(apiVersions) == 0 {
+		apiVersions = []APIVersion{V1}
+	}
 
-		// Check the package-level task marker is present or skip
-		pkgMarkers, err := markers.PackageMarkers(ctx.Collector, pkg)
-		if err != nil {
-			return err
-		}
+	// every API version is written in its own kustomize base
+	resources := make(map[APIVersion][]interface{}, len(apiVersions))
 
-		taskMarker := pkgMarkers.Get(ttmarkers.MarkerTask)
-		if taskMarker == nil {
-			// If no task marker is set on package, simply skip it
-			logger.Info("skipping non-task package")
+	for _, pkg := range ctx.Roots {
+		taskIR, err := g.Builder.Build(ctx.Collector, pkg)
+		if errors.Is(err, ir.ErrInvalidTask) {
+			// diagnostics are reported on the package
 			continue
 		}
 
-		task, err := g.initTask(taskMarker)
 		if err != nil {
 			return err
 		}
 
-		err = g.buildPackageDoc(task, pkg)
-		if err != nil {
-			return err
+		if taskIR == nil {
+			fileName, err := g.writePipeline(ctx, pkg, apiVersions)
+			if err != nil {
+				return err
+			}
+
+			if fileName != "" {
+				for _, apiVersion := range apiVersions {
+					resources[apiVersion] = append(resources[apiVersion], fileName)
+				}
+			}
+
+			continue
 		}
 
-		err = g.buildWorkspaces(task, pkgMarkers)
-		if err != nil {
-			return err
+		if taskIR.StepAction {
+			fileName, err := g.writeStepAction(ctx, taskIR, apiVersions)
+			if err != nil {
+				return err
+			}
+
+			for _, apiVersion := range apiVersions {
+				resources[apiVersion] = append(resources[apiVersion], fileName)
+			}
+
+			continue
 		}
 
-		// we keep a mapping from param and result name to scheme index
-		// to avoid duplicates
-		paramsIdx := make(map[string]int)
-		params := make([]interface{}, 0)
-		resultsIdx := make(map[string]int)
-		results := make([]interface{}, 0)
-
-		err = markers.EachType(ctx.Collector, pkg, func(info *markers.TypeInfo) {
-			rawParam := info.Markers.Get(ttmarkers.MarkerParam)
-			if rawParam != nil {
-				if param, ok := rawParam.(ttmarkers.Param); ok {
-					logger := logger.With("param", param.Name)
-					logger.Info("parameter found")
-
-					// ensure no duplication
-					if _, duplicate := paramsIdx[param.Name]; duplicate {
-						logger.Warn("parameter duplicated! ensure unique name")
-						return
-					}
-
-					paramsIdx[param.Name] = len(params)
-					builtParam, err := g.buildParam(param, info)
-					if err != nil {
-						logger.Warn("could not create param", "err", err)
-						return
-					}
-
-					params = append(params, builtParam)
-				}
+		for _, apiVersion := range apiVersions {
+			task, err := g.buildTask(taskIR, apiVersion)
+			if err != nil {
+				return err
 			}
 
-			rawResult := info.Markers.Get(ttmarkers.MarkerResult)
-			if rawResult != nil {
-				if result, ok := rawResult.(ttmarkers.Result); ok {
-					logger := logger.With("result", result.Name)
-					logger.Info("result found")
-
-					// ensure no duplication
-					if _, duplicate := resultsIdx[result.Name]; duplicate {
-						logger.Warn("result duplicated! ensure unique name")
-						return
-					}
-
-					resultsIdx[result.Name] = len(results)
-					builtResult, err := g.buildResult(result, info)
-					if err != nil {
-						logger.Warn("could not create result", "err", err)
-						return
-					}
-
-					results = append(results, builtResult)
-				}
+			fileName := task.GetName() + "-task.yaml"
+			err = ctx.WriteYAML(path.Join(apiVersion.BaseDir, fileName), "", []interface{}{task.Object})
+			if err != nil {
+				return err
 			}
-		})
 
-		if err != nil {
-			return err
+			resources[apiVersion] = append(resources[apiVersion], fileName)
+
+			err = g.writeSamples(ctx, taskIR, apiVersion)
+			if err != nil {
+				return err
+			}
 		}
+	}
 
-		err = unstructured.SetNestedSlice(task.Object, params, "spec", "params")
-		if err != nil {
-			return err
+	for _, apiVersion := range apiVersions {
+		if len(resources[apiVersion]) == 0 {
+			continue
 		}
 
-		err = unstructured.SetNestedSlice(task.Object, results, "spec", "results")
-		if err != nil {
-			return err
+		kustomization := map[string]interface{}{
+			"resources": resources[apiVersion],
 		}
 
-		err = g.buildSteps(task, pkg, params, results)
+		err := ctx.WriteYAML(path.Join(apiVersion.BaseDir, "kustomization.yaml"), "", []interface{}{kustomization})
 		if err != nil {
 			return err
 		}
+	}
 
-		kustomization := map[string]interface{}{
-			"resources": []interface{}{
-				task.GetName() + "-task.yaml",
-			},
-		}
+	return nil
+}
+
+// writeStepAction writes the StepAction in every kustomize base and returns the name of its file
+func (g TaskYamlGenerator) writeStepAction(ctx *genall.GenerationContext, taskIR *ir.Task, apiVersions []APIVersion) (string, error) {
+	version := g.StepActionVersion
+	if version == "" {
+		version = DefaultStepActionVersion
+	}
+
+	gv, err := LookupStepActionVersion(version)
+	if err != nil {
+		return "", err
+	}
 
-		err = ctx.WriteYAML("base/"+task.GetName()+"-task.yaml", "", []interface{}{task.Object})
+	stepAction, err := g.buildStepAction(taskIR, gv)
+	if err != nil {
+		return "", err
+	}
+
+	fileName := stepAction.GetName() + "-stepaction.yaml"
+	for _, apiVersion := range apiVersions {
+		err = ctx.WriteYAML(path.Join(apiVersion.BaseDir, fileName), "", []interface{}{stepAction.Object})
 		if err != nil {
-			return err
+			return "", err
 		}
+	}
+
+	return fileName, nil
+}
+
+// writePipeline writes the Pipeline of the package, if any, in every API version and
+// returns the name of its file
+func (g TaskYamlGenerator) writePipeline(ctx *genall.GenerationContext, pkg *loader.Package, apiVersions []APIVersion) (string, error) {
+	pipelineIR, err := g.Builder.BuildPipeline(ctx.Collector, pkg, ctx.Roots)
+	if errors.Is(err, ir.ErrInvalidPipeline) {
+		// diagnostics are reported on the package
+		return "", nil
+	}
+
+	if err != nil || pipelineIR == nil {
+		return "", err
+	}
 
-		err = ctx.WriteYAML("base/kustomization.yaml", "", []interface{}{kustomization})
+	fileName := pipelineIR.Name + "-pipeline.yaml"
+	for _, apiVersion := range apiVersions {
+		pipeline := g.buildPipeline(pipelineIR, apiVersion)
+		err = ctx.WriteYAML(path.Join(apiVersion.BaseDir, fileName), "", []interface{}{pipeline.Object})
 		if err != nil {
-			return err
+			return "", err
 		}
 	}
 
-	return nil
+	return fileName, nil
 }
 
-func (g TaskYamlGenerator) buildSteps(task unstructured.Unstructured, pkg *loader.Package, params, results []interface{}) error {
-	mainStep := map[string]interface{}{
-		"image": "ko://" + pkg.PkgPath,
+// writeSamples writes the samples showing how to use the Task next to it,
+// they are not resources of the kustomization so applying the base does not run them
+func (g TaskYamlGenerator) writeSamples(ctx *genall.GenerationContext, taskIR *ir.Task, apiVersion APIVersion) error {
+	taskRun := sample.TaskRun(taskIR, apiVersion.GroupVersion())
+	err := ctx.WriteYAML(path.Join(apiVersion.BaseDir, taskIR.Name+"-sample-taskrun.yaml"), "", []interface{}{taskRun.Object})
+	if err != nil || !g.SamplePipeline {
+		return err
 	}
 
-	// NB(raskyld): this code is dirty asf, we should be able to clean it when
-	// migrating from ad-hoc generation to intermediate representation (IR)
+	pipeline := sample.Pipeline(taskIR, apiVersion.GroupVersion())
+	return ctx.WriteYAML(path.Join(apiVersion.BaseDir, taskIR.Name+"-sample-pipeline.yaml"), "", []interface{}{pipeline.Object})
+}
 
-	command, err := g.parseFlagCommand(pkg)
+// buildTask renders the Task manifest for the given API version
+func (g TaskYamlGenerator) buildTask(taskIR *ir.Task, apiVersion APIVersion) (unstructured.Unstructured, error) {
+	task, err := g.initTask(taskIR, apiVersion)
 	if err != nil {
-		return err
+		return unstructured.Unstructured{}, err
 	}
 
-	cmds := strings.Split(command, " ")
-	icmds := make([]interface{}, len(cmds))
-	for i, cmd := range cmds {
-		icmds[i] = cmd
+	err = g.buildWorkspaces(task, taskIR)
+	if err != nil {
+		return unstructured.Unstructured{}, err
 	}
 
-	mainStep["command"] = icmds
+	params := make([]interface{}, 0, len(taskIR.Params))
+	for _, param := range taskIR.Params {
+		params = append(params, g.buildParam(param))
+	}
 
-	envs := make([]interface{}, 0)
-	for _, param := range params {
-		if param, ok := param.(map[string]interface{}); ok {
-			paramName := param["name"].(string)
-			envVarName := fmt.Sprintf("PARAM_%s_VALUE", strings.ToUpper(paramName))
-			paramValue := fmt.Sprintf("$(params[%s]", strconv.Quote(paramName))
+	results := make([]interface{}, 0, len(taskIR.Results))
+	for _, result := range taskIR.Results {
+		results = append(results, g.buildResult(result))
+	}
 
-			if param["type"].(string) != "string" {
-				paramValue += "[*])"
-			} else {
-				paramValue += ")"
-			}
+	err = unstructured.SetNestedSlice(task.Object, params, "spec", "params")
+	if err != nil {
+		return unstructured.Unstructured{}, err
+	}
 
-			envs = append(envs, map[string]interface{}{
-				"name":  envVarName,
-				"value": paramValue,
-			})
-		}
+	err = unstructured.SetNestedSlice(task.Object, results, "spec", "results")
+	if err != nil {
+		return unstructured.Unstructured{}, err
 	}
 
-	for _, result := range results {
-		if result, ok := result.(map[string]interface{}); ok {
-			resultName := result["name"].(string)
-			envVarName := fmt.Sprintf("RESULT_%s_PATH", strings.ToUpper(resultName))
-			resultValue := fmt.Sprintf("$(results[%s].path)", strconv.Quote(resultName))
+	err = g.buildSteps(task, taskIR, apiVersion)
+	if err != nil {
+		return unstructured.Unstructured{}, err
+	}
 
-			envs = append(envs, map[string]interface{}{
-				"name":  envVarName,
-				"value": resultValue,
-			})
-		}
+	err = g.buildSidecars(task, taskIR)
+	if err != nil {
+		return unstructured.Unstructured{}, err
 	}
 
-	if len(envs) > 0 {
-		mainStep["env"] = envs
+	return task, nil
+}
+
+func (g TaskYamlGenerator) buildSteps(task unstructured.Unstructured, taskIR *ir.Task, apiVersion APIVersion) error {
+	steps := make([]interface{}, 0, len(taskIR.Steps))
+	for _, step := range taskIR.Steps {
+		builtStep, err := g.buildStep(taskIR, step, apiVersion)
+		if err != nil {
+			return err
+		}
+
+		steps = append(steps, builtStep)
 	}
 
-	return unstructured.SetNestedSlice(task.Object, []interface{}{mainStep}, "spec", "steps")
+	return unstructured.SetNestedSlice(task.Object, steps, "spec", "steps")
 }
 
-// parseFlagCommand parses the flag `command` passed by the user
-func (g TaskYamlGenerator) parseFlagCommand(pkg *loader.Package) (string, error) {
-	tpl := &template.Template{}
-	tpl, err := tpl.Parse(g.StepCommand)
+func (g TaskYamlGenerator) buildStep(taskIR *ir.Task, step *ir.Step, apiVersion APIVersion) (map[string]interface{}, error) {
+	strategy, err := g.imageStrategyOf(taskIR)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	// TODO(raskyld): migrate this to an interface `KoEntrypointResolver` following the Strategy Pattern.
-	//                Each resolver should be registered with a ko version range
-	koAppName := path.Base(pkg.PkgPath)
-	if koAppName == "." || koAppName == string(filepath.Separator) {
-		koAppName = "ko-app"
+	image, cmds, err := strategy.Resolve(taskIR)
+	if err != nil {
+		return nil, err
 	}
 
-	args := stepCommandArgs{
-		PkgName:    pkg.Name,
-		ImportPath: pkg.PkgPath,
-		KoAppName:  koAppName,
+	builtStep := map[string]interface{}{
+		"image": image,
 	}
 
-	var command bytes.Buffer
-	err = tpl.Execute(&command, args)
-	if err != nil {
-		return "", err
+	if step.Image != "" {
+		builtStep["image"] = step.Image
+	}
+
+	if step.Name != "" {
+		builtStep["name"] = step.Name
 	}
-	return command.String(), nil
-}
 
-func (g TaskYamlGenerator) buildWorkspaces(task unstructured.Unstructured, pkgMarkers markers.MarkerValues) error {
-	if workspaces, ok := pkgMarkers[ttmarkers.MarkerWorkspace]; ok {
-		workspacesYaml := make([]interface{}, 0, len(workspaces))
-		for _, workspace := range workspaces {
-			if workspace, isWorkspace := workspace.(ttmarkers.Workspace); isWorkspace {
-				g.Logger.Info("found workspace", "workspace", workspace.Name)
-				workspaceYaml, err := g.buildWorkspace(workspace)
+	if step.Command != nil {
+		cmds = step.Command
+	}
 
-				if err != nil {
-					return err
-				}
+	if cmds != nil {
+		builtStep["command"] = stringSlice(cmds)
+	}
 
-				workspacesYaml = append(workspacesYaml, workspaceYaml)
-			}
+	args, stepEnv := ir.StepArgs(taskIR, step, tektonValues{stepAction: taskIR.StepAction})
+
+	envs := make([]interface{}, 0, len(stepEnv))
+	for _, env := range stepEnv {
+		envs = append(envs, map[string]interface{}{
+			"name":  env.Name,
+			"value": env.Value,
+		})
+	}
+
+	if len(args) > 0 {
+		builtStep["args"] = stringSlice(args)
+	}
+
+	if len(envs) > 0 {
+		builtStep["env"] = envs
+	}
+
+	if step.Resources != nil {
+		resources := make(map[string]interface{})
+		if len(step.Resources.Requests) > 0 {
+			resources["requests"] = stringMap(step.Resources.Requests)
 		}
 
-		err := unstructured.SetNestedSlice(task.Object, workspacesYaml, "spec", "workspaces")
-		if err != nil {
-			return err
+		if len(step.Resources.Limits) > 0 {
+			resources["limits"] = stringMap(step.Resources.Limits)
 		}
+
+		builtStep[apiVersion.ComputeResourcesField] = resources
 	}
-	return nil
+
+	if step.SecurityContext != nil {
+		builtStep["securityContext"] = g.buildSecurityContext(step.SecurityContext)
+	}
+
+	return builtStep, nil
 }
 
-func (g TaskYamlGenerator) initTask(taskMarker interface{}) (unstructured.Unstructured, error) {
-	var task unstructured.Unstructured
-	if taskMarker, ok := taskMarker.(ttmarkers.Task); ok {
-		task.SetName(taskMarker.Name)
-		task.SetLabels(map[string]string{
-			KubernetesVersionLabel: taskMarker.Version,
-		})
-	} else {
-		return unstructured.Unstructured{}, errors.New("unexpected wrong type for task marker")
+// tektonValues gives the Tekton variables holding the values of the step
+type tektonValues struct {
+	// stepAction uses the results of the step rather than the ones of the Task
+	stepAction bool
+}
+
+func (tektonValues) Param(param *ir.Param) string {
+	return fmt.Sprintf("$(params[%s])", strconv.Quote(param.Name))
+}
+
+func (tektonValues) Array(param *ir.Param) []string {
+	// Tekton only expands arrays in args or command
+	return []string{fmt.Sprintf("$(params[%s][*])", strconv.Quote(param.Name))}
+}
+
+func (tektonValues) Property(param *ir.Param, property *ir.Property) (string, bool) {
+	return fmt.Sprintf("$(params.%s.%s)", param.Name, property.Name), true
+}
+
+func (v tektonValues) Result(result *ir.Result) string {
+	if v.stepAction {
+		return fmt.Sprintf("$(step.results.%s.path)", result.Name)
 	}
 
-	// For now we only support the v1 api
-	task.SetGroupVersionKind(schema.GroupVersionKind{
-		Group:   "tekton.dev",
-		Version: "v1",
-		Kind:    "Task",
-	})
-	return task, nil
+	return fmt.Sprintf("$(results[%s].path)", strconv.Quote(result.Name))
 }
 
-// buildPackageDoc concatenates every package-level GoDoc to populate a Task description
-func (g TaskYamlGenerator) buildPackageDoc(task unstructured.Unstructured, pkg *loader.Package) error {
-	packagesDoc := make([]string, 0, len(pkg.Syntax))
-	for _, file := range pkg.Syntax {
-		if file != nil && file.Doc != nil {
-			packagesDoc = append(packagesDoc, file.Doc.Text())
-		}
+func (tektonValues) Workspace(workspace *ir.Workspace) string {
+	return fmt.Sprintf("$(workspaces.%s.path)", workspace.Name)
+}
+
+// imageStrategyOf returns the image strategy of the image marker of the Task or the default one
+func (g TaskYamlGenerator) imageStrategyOf(taskIR *ir.Task) (ImageStrategy, error) {
+	if taskIR.Image != nil {
+		return NewImageStrategy(taskIR.Image, g.StepCommand, g.KoResolver)
 	}
 
-	if len(packagesDoc) != 0 {
-		err := unstructured.SetNestedField(task.Object, strings.Join(packagesDoc, "\n"), "spec", "description")
-		if err != nil {
-			return err
-		}
+	if g.Image != nil {
+		return g.Image, nil
 	}
 
-	return nil
+	return KoImage{CommandTemplate: g.StepCommand, Resolver: g.KoResolver}, nil
+}
+
+func (g TaskYamlGenerator) buildSecurityContext(securityContext *ir.SecurityContext) map[string]interface{} {
+	rt := make(map[string]interface{})
+
+	if securityContext.RunAsNonRoot != nil {
+		rt["runAsNonRoot"] = *securityContext.RunAsNonRoot
+	}
+
+	if securityContext.RunAsUser != nil {
+		rt["runAsUser"] = *securityContext.RunAsUser
+	}
+
+	if securityContext.ReadOnlyRootFilesystem != nil {
+		rt["readOnlyRootFilesystem"] = *securityContext.ReadOnlyRootFilesystem
+	}
+
+	capabilities := make(map[string]interface{})
+	if len(securityContext.AddCapabilities) > 0 {
+		capabilities["add"] = stringSlice(securityContext.AddCapabilities)
+	}
+
+	if len(securityContext.DropCapabilities) > 0 {
+		capabilities["drop"] = stringSlice(securityContext.DropCapabilities)
+	}
+
+	if len(capabilities) > 0 {
+		rt["capabilities"] = capabilities
+	}
+
+	return rt
+}
+
+// stringSlice converts a slice of string to a slice usable by unstructured.Unstructured
+func stringSlice(values []string) []interface{} {
+	rt := make([]interface{}, len(values))
+	for i, value := range values {
+		rt[i] = value
+	}
+
+	return rt
 }
 
-func (g TaskYamlGenerator) buildParam(param ttmarkers.Param, typeInfo *markers.TypeInfo) (map[string]interface{}, error) {
+// stringMap converts a map of string to a map usable by unstructured.Unstructured
+func stringMap(values map[string]string) map[string]interface{} {
+	rt := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		rt[key] = value
+	}
+
+	return rt
+}
+
+func (g TaskYamlGenerator) buildSidecars(task unstructured.Unstructured, taskIR *ir.Task) error {
+	if len(taskIR.Sidecars) == 0 {
+		return nil
+	}
+
+	sidecars := make([]interface{}, 0, len(taskIR.Sidecars))
+	for _, sidecar := range taskIR.Sidecars {
+		sidecars = append(sidecars, g.buildSidecar(sidecar))
+	}
+
+	return unstructured.SetNestedSlice(task.Object, sidecars, "spec", "sidecars")
+}
+
+func (g TaskYamlGenerator) buildSidecar(sidecar *ir.Sidecar) map[string]interface{} {
 	rt := map[string]interface{}{
-		"name":        param.Name,
-		"description": typeInfo.Doc,
-	}
-
-	// First, we must figure out which Tekton type to use for the marked type
-	var tektonType string
-	switch typeInfo.RawSpec.Type.(type) {
-	case *ast.ArrayType:
-		tektonType = "array"
-	case *ast.MapType:
-		// Should be a valid json string though as
-		// we don't have a non-strict json schema type in Tekton
-		tektonType = "string"
-	case *ast.StructType:
-		// If the struct is not in strict mode then it does not have
-		// a determinist schema
-		if !param.Strict {
-			tektonType = "string"
-			break
+		"name":  sidecar.Name,
+		"image": sidecar.Image,
+	}
+
+	if len(sidecar.Command) > 0 {
+		rt["command"] = stringSlice(sidecar.Command)
+	}
+
+	if sidecar.ReadinessPort != 0 {
+		rt["readinessProbe"] = map[string]interface{}{
+			"tcpSocket": map[string]interface{}{
+				"port": int64(sidecar.ReadinessPort),
+			},
 		}
+	}
 
-		properties := make(map[string]interface{})
-		for _, field := range typeInfo.Fields {
-			tag, hasTag := field.Tag.Lookup("json")
-			if !hasTag {
-				return nil, errors.New("missing json tag on your strict struct")
-			}
+	return rt
+}
 
-			tags := strings.Split(tag, ",")
-			if len(tags[0]) == 0 {
-				return nil, errors.New("you need to give explicit json tag name to your struct")
-			} else {
-				if strings.HasPrefix(tags[0], "-") {
-					// Ignore this field
-					continue
-				} else {
-					if _, ok := properties[tags[0]]; ok {
-						return nil, errors.New("cannot use duplicate json tag name")
-					}
-
-					properties[tags[0]] = map[string]interface{}{
-						"type": "string",
-					}
-				}
-			}
+func (g TaskYamlGenerator) buildWorkspaces(task unstructured.Unstructured, taskIR *ir.Task) error {
+	if len(taskIR.Workspaces) == 0 {
+		return nil
+	}
+
+	workspacesYaml := make([]interface{}, 0, len(taskIR.Workspaces))
+	for _, workspace := range taskIR.Workspaces {
+		workspacesYaml = append(workspacesYaml, g.buildWorkspace(workspace))
+	}
+
+	return unstructured.SetNestedSlice(task.Object, workspacesYaml, "spec", "workspaces")
+}
+
+func (g TaskYamlGenerator) initTask(taskIR *ir.Task, apiVersion APIVersion) (unstructured.Unstructured, error) {
+	var task unstructured.Unstructured
+	task.SetName(taskIR.Name)
+	task.SetLabels(map[string]string{
+		KubernetesVersionLabel: taskIR.Version,
+	})
+
+	task.SetGroupVersionKind(apiVersion.GroupVersion().WithKind("Task"))
+
+	if annotations := catalogAnnotations(taskIR); len(annotations) > 0 {
+		task.SetAnnotations(annotations)
+	}
+
+	if taskIR.Description != "" {
+		err := unstructured.SetNestedField(task.Object, taskIR.Description, "spec", "description")
+		if err != nil {
+			return unstructured.Unstructured{}, err
 		}
+	}
 
-		rt["properties"] = properties
+	return task, nil
+}
 
-		tektonType = "object"
-	default:
-		tektonType = "string"
+func (g TaskYamlGenerator) buildParam(param *ir.Param) map[string]interface{} {
+	rt := map[string]interface{}{
+		"name":        param.Name,
+		"description": param.Description,
+		"type":        string(param.Type),
 	}
 
-	rt["type"] = tektonType
+	if param.Type == ir.TypeObject {
+		rt["properties"] = buildProperties(param.Properties)
+	}
 
 	if param.Default != nil {
-		defValue := *param.Default
-		switch tektonType {
-		case "array":
-			var defaultArray []interface{}
-			err := json.Unmarshal([]byte(defValue), &defaultArray)
-			rt["default"] = defaultArray
-			return rt, err
-		case "object":
-			// See TEP-0075 for how we should update and maintain this section
-			var object map[string]interface{}
-			err := json.Unmarshal([]byte(defValue), &object)
-			rt["default"] = object
-			return rt, err
-		default:
-			rt["default"] = defValue
+		rt["default"] = param.Default
+	}
+
+	// NB(raskyld): Tekton only enforces the enum when the enable-param-enum feature flag is on,
+	// the pattern has no Tekton counterpart and is only enforced by the generated Read
+	if len(param.Enum) > 0 {
+		enum := make([]interface{}, 0, len(param.Enum))
+		for _, value := range param.Enum {
+			enum = append(enum, value)
 		}
+
+		rt["enum"] = enum
 	}
 
-	return rt, nil
+	return rt
 }
 
-func (g TaskYamlGenerator) buildResult(result ttmarkers.Result, typeInfo *markers.TypeInfo) (map[string]interface{}, error) {
+func (g TaskYamlGenerator) buildResult(result *ir.Result) map[string]interface{} {
 	rt := map[string]interface{}{
 		"name":        result.Name,
-		"description": typeInfo.Doc,
+		"description": result.Description,
+		"type":        string(result.Type),
 	}
 
-	// First, we must figure out which Tekton type to use for the marked type
-	var tektonType string
-	switch typeInfo.RawSpec.Type.(type) {
-	case *ast.ArrayType:
-		tektonType = "array"
-	default:
-		tektonType = "string"
+	if result.Type == ir.TypeObject {
+		rt["properties"] = buildProperties(result.Properties)
 	}
 
-	rt["type"] = tektonType
-
-	return rt, nil
+	return rt
 }
 
-func (g TaskYamlGenerator) buildWorkspace(workspace ttmarkers.Workspace) (map[string]interface{}, error) {
+func (g TaskYamlGenerator) buildWorkspace(workspace *ir.Workspace) map[string]interface{} {
 	rt := map[string]interface{}{
 		"name":        workspace.Name,
 		"description": workspace.Description,
@@ -457,5 +568,17 @@ func (g TaskYamlGenerator) buildWorkspace(workspace ttmarkers.Workspace) (map[st
 		rt["mountPath"] = workspace.MountPath
 	}
 
-	return rt, nil
+	return rt
+}
+
+// buildProperties builds the properties of an object param or result
+func buildProperties(properties []*ir.Property) map[string]interface{} {
+	rt := make(map[string]interface{}, len(properties))
+	for _, property := range properties {
+		rt[property.Name] = map[string]interface{}{
+			"type": string(property.Type),
+		}
+	}
+
+	return rt
 }
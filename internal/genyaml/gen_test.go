@@ -0,0 +1,453 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package genyaml
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/raskyld/go-tektasker/internal/ir"
+	"golang.org/x/tools/go/packages"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/yaml"
+)
+
+// testTask returns a Task of the package example.com/tasks/hello
+// holding a single step running its main
+func testTask() *ir.Task {
+	return &ir.Task{
+		Name:    "hello",
+		Version: "0.1",
+		Package: &loader.Package{Package: &packages.Package{
+			Name:    "main",
+			PkgPath: "example.com/tasks/hello",
+		}},
+		Steps: []*ir.Step{{}},
+	}
+}
+
+// renderSpec builds the Task and renders the field at path in its spec as YAML,
+// the elements of a slice are indexed by their position, e.g. "steps", "0", "env"
+func renderSpec(t *testing.T, taskIR *ir.Task, apiVersion APIVersion, path ...string) string {
+	t.Helper()
+
+	g := TaskYamlGenerator{Image: FixedImage{Reference: "alpine@sha256:0"}}
+	task, err := g.buildTask(taskIR, apiVersion)
+	if err != nil {
+		t.Fatalf("buildTask() error = %v", err)
+	}
+
+	var field interface{} = task.Object["spec"]
+	for _, key := range path {
+		switch value := field.(type) {
+		case map[string]interface{}:
+			field = value[key]
+		case []interface{}:
+			i, err := strconv.Atoi(key)
+			if err != nil || i >= len(value) {
+				t.Fatalf("no element %s in %s", key, strings.Join(path, "."))
+			}
+
+			field = value[i]
+		}
+	}
+
+	rendered, err := yaml.Marshal(field)
+	if err != nil {
+		t.Fatalf("couldnt render %s: %s", strings.Join(path, "."), err.Error())
+	}
+
+	return string(rendered)
+}
+
+func TestBuildSidecars(t *testing.T) {
+	tests := []struct {
+		name     string
+		sidecars []*ir.Sidecar
+		result   string
+	}{
+		{
+			"No sidecar",
+			nil,
+			"null\n",
+		},
+		{
+			"Sidecar with its image entrypoint",
+			[]*ir.Sidecar{{Name: "registry", Image: "registry:2"}},
+			`- image: registry:2
+  name: registry
+`,
+		},
+		{
+			"Sidecar with a command and a readiness port",
+			[]*ir.Sidecar{{Name: "redis", Image: "redis:7", Command: []string{"redis-server", "--save"}, ReadinessPort: 6379}},
+			`- command:
+  - redis-server
+  - --save
+  image: redis:7
+  name: redis
+  readinessProbe:
+    tcpSocket:
+      port: 6379
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			taskIR := testTask()
+			taskIR.Sidecars = test.sidecars
+
+			if result := renderSpec(t, taskIR, V1, "sidecars"); result != test.result {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", result, test.result)
+			}
+		})
+	}
+}
+
+func TestBuildStepResources(t *testing.T) {
+	nonRoot := true
+	uid := int64(1000)
+
+	tests := []struct {
+		name       string
+		apiVersion APIVersion
+		step       *ir.Step
+		result     string
+	}{
+		{
+			"Compute resources of v1",
+			V1,
+			&ir.Step{Resources: &ir.Resources{
+				Requests: map[string]string{"cpu": "100m"},
+				Limits:   map[string]string{"memory": "128Mi"},
+			}},
+			`computeResources:
+  limits:
+    memory: 128Mi
+  requests:
+    cpu: 100m
+image: alpine@sha256:0
+`,
+		},
+		{
+			"Resources of v1beta1",
+			V1Beta1,
+			&ir.Step{Resources: &ir.Resources{
+				Requests: map[string]string{"cpu": "100m"},
+				Limits:   map[string]string{},
+			}},
+			`image: alpine@sha256:0
+resources:
+  requests:
+    cpu: 100m
+`,
+		},
+		{
+			"Security context",
+			V1,
+			&ir.Step{SecurityContext: &ir.SecurityContext{
+				RunAsNonRoot:     &nonRoot,
+				RunAsUser:        &uid,
+				DropCapabilities: []string{"ALL"},
+			}},
+			`image: alpine@sha256:0
+securityContext:
+  capabilities:
+    drop:
+    - ALL
+  runAsNonRoot: true
+  runAsUser: 1000
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			taskIR := testTask()
+			taskIR.Steps = []*ir.Step{test.step}
+
+			if result := renderSpec(t, taskIR, test.apiVersion, "steps", "0"); result != test.result {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", result, test.result)
+			}
+		})
+	}
+}
+
+func TestBuildStepEnv(t *testing.T) {
+	params := []*ir.Param{
+		{Name: "url", Type: ir.TypeString, EnvVar: ir.ParamEnvVar("url")},
+		{Name: "files", Type: ir.TypeArray},
+		{Name: "repo", Type: ir.TypeObject, Properties: []*ir.Property{
+			{Name: "revision", EnvVar: ir.ParamEnvVar("repo.revision")},
+		}},
+	}
+
+	results := []*ir.Result{{Name: "digest", EnvVar: ir.ResultEnvVar("digest")}}
+
+	tests := []struct {
+		name       string
+		stepAction bool
+		step       *ir.Step
+		workspaces []*ir.Workspace
+		result     string
+	}{
+		{
+			"Params and results of the step",
+			false,
+			&ir.Step{Params: params, Results: results},
+			nil,
+			`args:
+- --tektasker-array-begin=files
+- $(params["files"][*])
+- --tektasker-array-end=files
+env:
+- name: PARAM_URL_VALUE
+  value: $(params["url"])
+- name: PARAM_REPO_REVISION_VALUE
+  value: $(params.repo.revision)
+- name: RESULT_DIGEST_PATH
+  value: $(results["digest"].path)
+image: alpine@sha256:0
+`,
+		},
+		{
+			"Results of a stepaction",
+			true,
+			&ir.Step{Results: results},
+			nil,
+			`env:
+- name: RESULT_DIGEST_PATH
+  value: $(step.results.digest.path)
+image: alpine@sha256:0
+`,
+		},
+		{
+			"Workspaces of the task and entrypoint of the step",
+			false,
+			&ir.Step{Name: "build", Entrypoint: "build"},
+			[]*ir.Workspace{{Name: "source", EnvVar: ir.WorkspaceEnvVar("source")}},
+			`env:
+- name: WORKSPACE_SOURCE_PATH
+  value: $(workspaces.source.path)
+- name: TEKTASKER_STEP
+  value: build
+image: alpine@sha256:0
+name: build
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			taskIR := testTask()
+			taskIR.StepAction = test.stepAction
+			taskIR.Steps = []*ir.Step{test.step}
+			taskIR.Workspaces = test.workspaces
+
+			if result := renderSpec(t, taskIR, V1, "steps", "0"); result != test.result {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", result, test.result)
+			}
+		})
+	}
+}
+
+func TestBuildPipeline(t *testing.T) {
+	greet := testTask()
+	greet.Name = "greet"
+
+	pipelineIR := &ir.Pipeline{
+		Name:        "greetings",
+		Version:     "0.1",
+		Description: "Greetings greets twice",
+		Params:      []*ir.Param{{Name: "name", Description: "Name of the greeted", Type: ir.TypeString}},
+		Workspaces:  []*ir.Workspace{{Name: "shared", Description: "Shared between the tasks"}},
+		Tasks: []*ir.PipelineTask{
+			{
+				Name:       "first",
+				Task:       greet,
+				Params:     []*ir.ParamValue{{Name: "who", Value: "$(params.name)"}},
+				Workspaces: []*ir.WorkspaceBinding{{Name: "output", Workspace: "shared"}},
+			},
+			{
+				Name:     "second",
+				Task:     greet,
+				RunAfter: []string{"first"},
+				Params:   []*ir.ParamValue{{Name: "who", Value: "$(tasks.first.results.greeting)"}},
+			},
+		},
+	}
+
+	pipeline := TaskYamlGenerator{}.buildPipeline(pipelineIR, V1Beta1)
+	result, err := yaml.Marshal(pipeline.Object)
+	if err != nil {
+		t.Fatalf("couldnt render the pipeline: %s", err.Error())
+	}
+
+	wanted := `apiVersion: tekton.dev/v1beta1
+kind: Pipeline
+metadata:
+  labels:
+    app.kubernetes.io/version: "0.1"
+  name: greetings
+spec:
+  description: Greetings greets twice
+  params:
+  - description: Name of the greeted
+    name: name
+    type: string
+  tasks:
+  - name: first
+    params:
+    - name: who
+      value: $(params.name)
+    taskRef:
+      name: greet
+    workspaces:
+    - name: output
+      workspace: shared
+  - name: second
+    params:
+    - name: who
+      value: $(tasks.first.results.greeting)
+    runAfter:
+    - first
+    taskRef:
+      name: greet
+  workspaces:
+  - description: Shared between the tasks
+    name: shared
+    optional: false
+`
+
+	if string(result) != wanted {
+		t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", result, wanted)
+	}
+}
+
+func TestBuildStepAction(t *testing.T) {
+	param := &ir.Param{Name: "url", Description: "URL to fetch", Type: ir.TypeString, EnvVar: ir.ParamEnvVar("url")}
+	result := &ir.Result{Name: "digest", Description: "Digest of the content", Type: ir.TypeString, EnvVar: ir.ResultEnvVar("digest")}
+
+	tests := []struct {
+		name    string
+		version string
+		params  []*ir.Param
+		results []*ir.Result
+		wantErr bool
+		result  string
+	}{
+		{
+			"Params and results of v1alpha1",
+			"v1alpha1",
+			[]*ir.Param{param},
+			[]*ir.Result{result},
+			false,
+			`apiVersion: tekton.dev/v1alpha1
+kind: StepAction
+metadata:
+  labels:
+    app.kubernetes.io/version: "0.1"
+  name: hello
+spec:
+  description: Hello fetches an URL
+  env:
+  - name: PARAM_URL_VALUE
+    value: $(params["url"])
+  - name: RESULT_DIGEST_PATH
+    value: $(step.results.digest.path)
+  image: alpine@sha256:0
+  params:
+  - description: URL to fetch
+    name: url
+    type: string
+  results:
+  - description: Digest of the content
+    name: digest
+    type: string
+`,
+		},
+		{
+			"Results only of v1beta1",
+			"v1beta1",
+			nil,
+			[]*ir.Result{result},
+			false,
+			`apiVersion: tekton.dev/v1beta1
+kind: StepAction
+metadata:
+  labels:
+    app.kubernetes.io/version: "0.1"
+  name: hello
+spec:
+  description: Hello fetches an URL
+  env:
+  - name: RESULT_DIGEST_PATH
+    value: $(step.results.digest.path)
+  image: alpine@sha256:0
+  results:
+  - description: Digest of the content
+    name: digest
+    type: string
+`,
+		},
+		{
+			"Unsupported version",
+			"v1",
+			nil,
+			nil,
+			true,
+			"",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gv, err := LookupStepActionVersion(test.version)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("LookupStepActionVersion() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			taskIR := testTask()
+			taskIR.StepAction = true
+			taskIR.Description = "Hello fetches an URL"
+			taskIR.Params = test.params
+			taskIR.Results = test.results
+			taskIR.Steps = []*ir.Step{{Params: test.params, Results: test.results}}
+
+			g := TaskYamlGenerator{Image: FixedImage{Reference: "alpine@sha256:0"}}
+			stepAction, err := g.buildStepAction(taskIR, gv)
+			if err != nil {
+				t.Fatalf("buildStepAction() error = %v", err)
+			}
+
+			result, err := yaml.Marshal(stepAction.Object)
+			if err != nil {
+				t.Fatalf("couldnt render the stepaction: %s", err.Error())
+			}
+
+			if string(result) != test.result {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", result, test.result)
+			}
+		})
+	}
+}
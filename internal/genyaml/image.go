@@ -0,0 +1,161 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package genyaml
+
+import (
+	"bytes"
+	"github.com/raskyld/go-tektasker/internal/ir"
+	"strings"
+	"text/template"
+)
+
+// ImageStrategy resolves the container image running the Go package of a Task
+// and the command of its steps
+type ImageStrategy interface {
+	// Resolve returns the image reference and the command of the steps,
+	// a nil command keeps the entrypoint of the image
+	Resolve(taskIR *ir.Task) (image string, command []string, err error)
+}
+
+// imageArgs is passed when templating an image reference or a command
+type imageArgs struct {
+	// TaskName is the name of the Task
+	TaskName string
+
+	// Version of the Task
+	Version string
+
+	// PkgName is the name of the package being parsed
+	PkgName string
+
+	// ImportPath of the package
+	ImportPath string
+
+	// KoAppName is the name of the binary built by ko
+	KoAppName string
+
+	// KoEntrypoint is the absolute path of the binary built by ko
+	KoEntrypoint string
+}
+
+// NewImageStrategy returns the strategy of the image, stepCommand is the command
+// template of the ko strategy when the image does not set its command
+// and koResolver names the binaries built by ko in the templates
+func NewImageStrategy(image *ir.Image, stepCommand string, koResolver KoEntrypointResolver) (ImageStrategy, error) {
+	err := image.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	switch image.Strategy {
+	case ir.ImageStrategyFixed:
+		return FixedImage{Reference: image.Reference, Command: image.Command}, nil
+	case ir.ImageStrategyTemplate:
+		return TemplateImage{Template: image.Reference, Command: image.Command, Resolver: koResolver}, nil
+	default:
+		if image.Command != nil {
+			return KoImage{Command: image.Command, Resolver: koResolver}, nil
+		}
+
+		return KoImage{CommandTemplate: stepCommand, Resolver: koResolver}, nil
+	}
+}
+
+// KoImage builds the image with ko from the import path of the package
+type KoImage struct {
+	// CommandTemplate is the template of the command, split on spaces
+	CommandTemplate string
+
+	// Command overrides CommandTemplate
+	Command []string
+
+	// Resolver names the binary built by ko
+	Resolver KoEntrypointResolver
+}
+
+func (k KoImage) Resolve(taskIR *ir.Task) (string, []string, error) {
+	image := "ko://" + taskIR.Package.PkgPath
+	if k.Command != nil {
+		return image, k.Command, nil
+	}
+
+	command, err := executeTemplate(k.CommandTemplate, taskIR, k.Resolver)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return image, strings.Split(command, " "), nil
+}
+
+// FixedImage uses an image reference pinned by digest,
+// e.g. built by Bazel rules_oci or from a Dockerfile
+type FixedImage struct {
+	Reference string
+	Command   []string
+}
+
+func (f FixedImage) Resolve(*ir.Task) (string, []string, error) {
+	return f.Reference, f.Command, nil
+}
+
+// TemplateImage computes the image reference from a template
+type TemplateImage struct {
+	Template string
+	Command  []string
+
+	// Resolver names the binary built by ko when the template refers to it
+	Resolver KoEntrypointResolver
+}
+
+func (t TemplateImage) Resolve(taskIR *ir.Task) (string, []string, error) {
+	image, err := executeTemplate(t.Template, taskIR, t.Resolver)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return image, t.Command, nil
+}
+
+// executeTemplate executes a template of an image reference or a command for the Task
+func executeTemplate(text string, taskIR *ir.Task, resolver KoEntrypointResolver) (string, error) {
+	tpl, err := template.New("image").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	pkg := taskIR.Package
+	if resolver == nil {
+		resolver = BaseKoResolver{}
+	}
+
+	args := imageArgs{
+		TaskName:     taskIR.Name,
+		Version:      taskIR.Version,
+		PkgName:      pkg.Name,
+		ImportPath:   pkg.PkgPath,
+		KoAppName:    resolver.AppName(pkg.PkgPath),
+		KoEntrypoint: KoEntrypoint(resolver, pkg.PkgPath),
+	}
+
+	var rendered bytes.Buffer
+	err = tpl.Execute(&rendered, args)
+	if err != nil {
+		return "", err
+	}
+
+	return rendered.String(), nil
+}
@@ -0,0 +1,126 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package genyaml
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/raskyld/go-tektasker/internal/ir"
+)
+
+func TestNewImageStrategy(t *testing.T) {
+	tests := []struct {
+		name       string
+		image      *ir.Image
+		wantErr    bool
+		resolveErr bool
+		result     string
+		command    []string
+	}{
+		{
+			"Ko with the step command",
+			&ir.Image{Strategy: ir.ImageStrategyKo},
+			false,
+			false,
+			"ko://example.com/tasks/hello",
+			[]string{"/ko-app/hello", "--verbose"},
+		},
+		{
+			"Ko with a command",
+			&ir.Image{Strategy: ir.ImageStrategyKo, Command: []string{"/app"}},
+			false,
+			false,
+			"ko://example.com/tasks/hello",
+			[]string{"/app"},
+		},
+		{
+			"Fixed",
+			&ir.Image{Strategy: ir.ImageStrategyFixed, Reference: "registry.example.com/hello@sha256:0"},
+			false,
+			false,
+			"registry.example.com/hello@sha256:0",
+			nil,
+		},
+		{
+			"Fixed not pinned by digest",
+			&ir.Image{Strategy: ir.ImageStrategyFixed, Reference: "registry.example.com/hello:0.1"},
+			true,
+			false,
+			"",
+			nil,
+		},
+		{
+			"Template",
+			&ir.Image{
+				Strategy:  ir.ImageStrategyTemplate,
+				Reference: "registry.example.com/{{.PkgName}}/{{.KoAppName}}:{{.TaskName}}-{{.Version}}",
+				Command:   []string{"/app"},
+			},
+			false,
+			false,
+			"registry.example.com/main/hello:hello-0.1",
+			[]string{"/app"},
+		},
+		{
+			"Template with a syntax error",
+			&ir.Image{Strategy: ir.ImageStrategyTemplate, Reference: "registry.example.com/{{.TaskName"},
+			false,
+			true,
+			"",
+			nil,
+		},
+		{
+			"Template with an unknown field",
+			&ir.Image{Strategy: ir.ImageStrategyTemplate, Reference: "registry.example.com/{{.Unknown}}"},
+			false,
+			true,
+			"",
+			nil,
+		},
+		{
+			"Unknown strategy",
+			&ir.Image{Strategy: "docker"},
+			true,
+			false,
+			"",
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			strategy, err := NewImageStrategy(test.image, "{{.KoEntrypoint}} --verbose", BaseKoResolver{})
+			if (err != nil) != test.wantErr {
+				t.Fatalf("NewImageStrategy() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			result, command, err := strategy.Resolve(testTask())
+			if (err != nil) != test.resolveErr {
+				t.Fatalf("Resolve() error = %v, wantErr %v", err, test.resolveErr)
+			}
+
+			if result != test.result || !reflect.DeepEqual(command, test.command) {
+				t.Errorf("unwanted diff, got\n---\n%s %v\n---\nwanted\n---\n%s %v", result, command, test.result, test.command)
+			}
+		})
+	}
+}
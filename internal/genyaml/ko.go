@@ -0,0 +1,49 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package genyaml
+
+import (
+	"path"
+)
+
+// KoAppDir is the directory ko copies the binary of a package to
+const KoAppDir = "/ko-app"
+
+// KoEntrypointResolver replicates how ko names the binary
+// of a package in the image it builds
+type KoEntrypointResolver interface {
+	// AppName is the name of the binary built from the package
+	AppName(importPath string) string
+}
+
+// KoEntrypoint is the absolute path of the binary of the package in the image built by ko
+func KoEntrypoint(resolver KoEntrypointResolver, importPath string) string {
+	return path.Join(KoAppDir, resolver.AppName(importPath))
+}
+
+// BaseKoResolver names the binary after the last element of the import path,
+// a package at the root of a module without path falls back to ko-app
+type BaseKoResolver struct{}
+
+func (BaseKoResolver) AppName(importPath string) string {
+	base := path.Base(importPath)
+	if base == "." || base == "/" {
+		return "ko-app"
+	}
+
+	return base
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package genyaml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKoEntrypoint(t *testing.T) {
+	tests := []struct {
+		name       string
+		importPath string
+		result     string
+	}{
+		{"Package", "example.com/tasks/cmd/hello", "/ko-app/hello"},
+		{"Root package", "hello", "/ko-app/hello"},
+		{"Major version", "example.com/hello/v2", "/ko-app/v2"},
+		{"Package in a major version", "example.com/hello/v2/cmd/greet", "/ko-app/greet"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := KoEntrypoint(BaseKoResolver{}, test.importPath)
+			if !reflect.DeepEqual(result, test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", result, test.result)
+			}
+		})
+	}
+}
+
+func TestBaseKoResolverFallback(t *testing.T) {
+	for _, importPath := range []string{"", "/", "."} {
+		result := BaseKoResolver{}.AppName(importPath)
+		if result != "ko-app" {
+			t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", result, "ko-app")
+		}
+	}
+}
@@ -0,0 +1,110 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package genyaml
+
+import (
+	"github.com/raskyld/go-tektasker/internal/ir"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// buildPipeline renders the Pipeline manifest for the given API version
+func (g TaskYamlGenerator) buildPipeline(pipelineIR *ir.Pipeline, apiVersion APIVersion) unstructured.Unstructured {
+	var pipeline unstructured.Unstructured
+	pipeline.SetName(pipelineIR.Name)
+	pipeline.SetLabels(map[string]string{
+		KubernetesVersionLabel: pipelineIR.Version,
+	})
+
+	pipeline.SetGroupVersionKind(apiVersion.GroupVersion().WithKind("Pipeline"))
+
+	spec := make(map[string]interface{})
+
+	if pipelineIR.Description != "" {
+		spec["description"] = pipelineIR.Description
+	}
+
+	if len(pipelineIR.Params) > 0 {
+		params := make([]interface{}, 0, len(pipelineIR.Params))
+		for _, param := range pipelineIR.Params {
+			params = append(params, g.buildParam(param))
+		}
+
+		spec["params"] = params
+	}
+
+	if len(pipelineIR.Workspaces) > 0 {
+		workspaces := make([]interface{}, 0, len(pipelineIR.Workspaces))
+		for _, workspace := range pipelineIR.Workspaces {
+			workspaces = append(workspaces, map[string]interface{}{
+				"name":        workspace.Name,
+				"description": workspace.Description,
+				"optional":    workspace.Optional,
+			})
+		}
+
+		spec["workspaces"] = workspaces
+	}
+
+	tasks := make([]interface{}, 0, len(pipelineIR.Tasks))
+	for _, task := range pipelineIR.Tasks {
+		tasks = append(tasks, buildPipelineTask(task))
+	}
+
+	spec["tasks"] = tasks
+
+	pipeline.Object["spec"] = spec
+	return pipeline
+}
+
+func buildPipelineTask(task *ir.PipelineTask) map[string]interface{} {
+	rt := map[string]interface{}{
+		"name": task.Name,
+		"taskRef": map[string]interface{}{
+			"name": task.Task.Name,
+		},
+	}
+
+	if len(task.RunAfter) > 0 {
+		rt["runAfter"] = stringSlice(task.RunAfter)
+	}
+
+	if len(task.Params) > 0 {
+		params := make([]interface{}, 0, len(task.Params))
+		for _, param := range task.Params {
+			params = append(params, map[string]interface{}{
+				"name":  param.Name,
+				"value": param.Value,
+			})
+		}
+
+		rt["params"] = params
+	}
+
+	if len(task.Workspaces) > 0 {
+		workspaces := make([]interface{}, 0, len(task.Workspaces))
+		for _, binding := range task.Workspaces {
+			workspaces = append(workspaces, map[string]interface{}{
+				"name":      binding.Name,
+				"workspace": binding.Workspace,
+			})
+		}
+
+		rt["workspaces"] = workspaces
+	}
+
+	return rt
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package genyaml
+
+import (
+	"github.com/raskyld/go-tektasker/internal/ir"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// buildStepAction renders the StepAction manifest, its spec is the single step
+// of the package along with the params and results it declares
+func (g TaskYamlGenerator) buildStepAction(taskIR *ir.Task, gv schema.GroupVersion) (unstructured.Unstructured, error) {
+	var stepAction unstructured.Unstructured
+	stepAction.SetName(taskIR.Name)
+	stepAction.SetLabels(map[string]string{
+		KubernetesVersionLabel: taskIR.Version,
+	})
+
+	stepAction.SetGroupVersionKind(gv.WithKind("StepAction"))
+
+	// compute resources can not be set on a StepAction so the API version of the step does not matter
+	spec, err := g.buildStep(taskIR, taskIR.Steps[0], V1)
+	if err != nil {
+		return unstructured.Unstructured{}, err
+	}
+
+	if taskIR.Description != "" {
+		spec["description"] = taskIR.Description
+	}
+
+	if len(taskIR.Params) > 0 {
+		params := make([]interface{}, 0, len(taskIR.Params))
+		for _, param := range taskIR.Params {
+			params = append(params, g.buildParam(param))
+		}
+
+		spec["params"] = params
+	}
+
+	if len(taskIR.Results) > 0 {
+		results := make([]interface{}, 0, len(taskIR.Results))
+		for _, result := range taskIR.Results {
+			results = append(results, g.buildResult(result))
+		}
+
+		spec["results"] = results
+	}
+
+	stepAction.Object["spec"] = spec
+	return stepAction, nil
+}
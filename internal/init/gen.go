@@ -48,6 +48,7 @@ func New(logger *slog.Logger, taskName string) *Generator {
 
 	gen.RegisterTemplate(ttemplate.TaskfileName, ttemplate.TaskfileTpl).
 		RegisterTemplate(ttemplate.DotEnvName, ttemplate.DotEnvTpl).
+		RegisterTemplate(ttemplate.ConfigName, ttemplate.ConfigTpl).
 		RegisterTemplate(ttemplate.TaskGoName, ttemplate.TaskGoTpl)
 
 	return gen
@@ -78,6 +79,11 @@ func (g *Generator) Generate(output genall.OutputRule) error {
 		return err
 	}
 
+	err = g.genFile(output, ttemplate.ConfigName, "tektasker.yaml", nil)
+	if err != nil {
+		return err
+	}
+
 	err = g.genFile(output, ttemplate.TaskGoName, "task.go", ttemplate.TaskGoArgs{TaskName: g.TaskName})
 	if err != nil {
 		return err
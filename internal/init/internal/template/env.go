@@ -18,7 +18,8 @@ package template
 
 const DotEnvName = "dotenv"
 const DotEnvTpl = `# This .env is generated by tektasker init
-# and contains configuration for your development environment
+# and contains configuration for your development environment,
+# the generation is configured in tektasker.yaml
 #
 # IMPORTANT: Please, remember this should not be used to push to production!
 # It is recommended instead to setup a CI/CD that will publish the container image
@@ -40,35 +41,13 @@ KO_DOCKER_REPO=ko.local
 # ---
 
 # Kustomization overlay to use when running "task apply"
-APPLY_OVERLAY="base"
+# Tips: manifest.output of tektasker.yaml holds the base generated by Tektasker
+APPLY_OVERLAY="deployment/base"
 
 # Only apply to this specific kubectl context
 # If left empty, use the current-context
 APPLY_CONTEXT=""
 
-# ---
-# OUTPUT
-# Where to write generated code and manifests?
-# ---
-
-# Name to use for the package containing tektasker internal helper code
-OUTPUT_INTERNAL_PKG_NAME="tekton"
-
-# Path to your internal non-reusable go packages
-# Tektasker will add its internal package in it
-OUTPUT_INTERNAL_PKGS="internal"
-
-# Place where to write the Task Kustomization
-#
-# Example:
-# If    OUTPUT_MANIFESTS=deployment
-# Then  deployment/base will be a kustomization base generated by Tektasker.
-#       You can then create overlays over of this base, for example, to add a
-#       deployment/with-volumes overlay that will add volumes to your task.
-#       If you use such a setup, you should update APPLY_OVERLAY to point to the
-#       overlay you wish to apply.
-OUTPUT_MANIFESTS=deployment
-
 # ---
 # Binaries PATH
 # Where are the required tools?
@@ -49,27 +49,39 @@ tasks:
       - "{{Raw ".PATH_KO"}} version | sed 's/^/\t/'"
       - "echo 'tektasker version:'"
       - "{{Raw ".PATH_TEKTASKER"}} version | sed 's/^/\t/'"
-      - "echo 'destination for manifests:'"
-      - "echo '\tOUTPUT_MANIFESTS={{Raw ".OUTPUT_MANIFESTS"}}'"
+      - "echo 'generation configuration:'"
+      - "grep -v -e '^ *#' -e '^ *$' tektasker.yaml | sed 's/^/\t/'"
       - "echo 'kustomize overlay deployed by task apply:'"
       - "echo '\tAPPLY_OVERLAY={{Raw ".APPLY_OVERLAY"}}'"
 
   manifest:
     desc: Generate your Task manifest as a Kustomization
     cmds:
-      - "{{Raw ".PATH_TEKTASKER"}} generate manifest {{Raw ".OUTPUT_MANIFESTS"}}"
+      - "{{Raw ".PATH_TEKTASKER"}} generate manifest"
 
   generate:
     desc: Generate Go code for your project (run it everytime you change markers)
     cmds:
-      - "{{Raw ".PATH_TEKTASKER"}} generate go {{Raw ".OUTPUT_INTERNAL_PKGS"}} {{Raw ".OUTPUT_INTERNAL_PKG_NAME"}}"
+      - "{{Raw ".PATH_TEKTASKER"}} generate go"
+
+  docs:
+    desc: Generate the README of your Task from its GoDoc
+    cmds:
+      - "{{Raw ".PATH_TEKTASKER"}} generate docs"
+
+  verify:
+    desc: Fail if the generated code or manifests are stale (run it in your CI)
+    cmds:
+      - "{{Raw ".PATH_TEKTASKER"}} generate --verify go"
+      - "{{Raw ".PATH_TEKTASKER"}} generate --verify manifest"
+      - "{{Raw ".PATH_TEKTASKER"}} generate --verify docs"
 
   apply:
     deps: ["generate", "manifest"]
     desc: Apply the changes on your current Kubernetes context
     cmds:
       - cmd: |
-          {{Raw ".PATH_KUBECTL"}} kustomize {{Raw ".OUTPUT_MANIFESTS"}}/{{Raw ".APPLY_OVERLAY"}} | \
+          {{Raw ".PATH_KUBECTL"}} kustomize {{Raw ".APPLY_OVERLAY"}} | \
           {{Raw ".PATH_KO"}} resolve -f - | \
           {{Raw ".PATH_KUBECTL"}} apply -f -{{Raw "if .APPLY_CONTEXT"}} --context {{Raw ".APPLY_CONTEXT"}}{{Raw "end"}}
 `
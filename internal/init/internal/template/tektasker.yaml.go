@@ -0,0 +1,79 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package template
+
+const ConfigName = "config"
+const ConfigTpl = `# This tektasker.yaml is generated by tektasker init
+# and is the configuration of the generation of your project.
+# The flags of go-tektasker take precedence over it.
+
+# Packages to generate code and manifests for
+input: "."
+
+# Boilerplate header to put at the top of the generated Go code
+# Tips: ' YEAR' will be replaced with the current year
+headerFile: ""
+
+go:
+  # Path to your internal non-reusable go packages
+  # Tektasker will add its internal package in it
+  internalPkgPath: internal
+  # Name to use for the package containing tektasker internal helper code
+  internalPkgName: tekton
+
+manifest:
+  # Place where to write the Task Kustomization
+  #
+  # Example:
+  # If    output: deployment
+  # Then  deployment/base will be a kustomization base generated by Tektasker.
+  #       You can then create overlays over of this base, for example, to add a
+  #       deployment/with-volumes overlay that will add volumes to your task.
+  #       If you use such a setup, you should update APPLY_OVERLAY in your .env
+  #       to point to the overlay you wish to apply.
+  output: deployment
+  # Entrypoint of the container image of the steps built with ko
+  command: "ko-app/{{Raw ".KoAppName"}}"
+  # How the image of the steps is built, a Task can override it with the image marker:
+  #   ko        the image is built by ko from the import path of the package
+  #   fixed     reference is an image reference pinned by digest, e.g. built by Bazel rules_oci
+  #   template  reference is the template of the image reference, e.g. built from a Dockerfile
+  # The fixed and template strategies run the entrypoint of the image unless command is set.
+  image:
+    strategy: ko
+    # reference: "registry.example.com/{{Raw ".TaskName"}}:{{Raw ".Version"}}"
+    # command: ["/app"]
+  # Tekton API versions to emit, v1 is written in base/ and v1beta1 in base-v1beta1/
+  apiVersions:
+    - v1
+  # Tekton API version of the StepAction manifests (v1alpha1, v1beta1)
+  stepActionVersion: v1beta1
+  # A sample TaskRun is written next to the Task, set it to also write a sample Pipeline
+  samplePipeline: false
+
+# Where "go-tektasker generate docs" writes the README of your Task
+docs:
+  output: docs
+  # Tekton API version of the usage examples (v1, v1beta1)
+  apiVersion: v1
+
+# Uncomment to publish your Task to a Tekton Catalog with "go-tektasker generate catalog",
+# it is written in task/<name>/<version>/ of the output directory
+# catalog:
+#   output: catalog
+#   apiVersion: v1
+`
@@ -0,0 +1,97 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ir
+
+// Tekton can only expand an array param in the `args` or `command` of a step, so array
+// params are passed as arguments of the step, their elements being delimited by
+// ArrayArgBegin and ArrayArgEnd suffixed with the name of the param, e.g.
+//
+//	--tektasker-array-begin=files a.txt b.txt --tektasker-array-end=files
+const (
+	ArrayArgBegin = "--tektasker-array-begin="
+	ArrayArgEnd   = "--tektasker-array-end="
+)
+
+// ArrayArgs returns the arguments passing the values of the array param to a step
+func ArrayArgs(param *Param, values ...string) []string {
+	args := make([]string, 0, len(values)+2)
+	args = append(args, ArrayArgBegin+param.Name)
+	args = append(args, values...)
+	return append(args, ArrayArgEnd+param.Name)
+}
+
+// StepValues gives the values passed to a step, either the Tekton variables
+// of the Task manifest or the actual values of a local run
+type StepValues interface {
+	// Param returns the value of a string param
+	Param(param *Param) string
+
+	// Array returns the elements of an array param
+	Array(param *Param) []string
+
+	// Property returns the value of a property of an object param, false to leave it unset
+	Property(param *Param, property *Property) (string, bool)
+
+	// Result returns the path the step writes a result to
+	Result(result *Result) string
+
+	// Workspace returns the path of a workspace
+	Workspace(workspace *Workspace) string
+}
+
+// StepEnv is an environment variable of a step
+type StepEnv struct {
+	Name  string
+	Value string
+}
+
+// StepArgs returns the arguments and the environment of a step: arrays are passed as
+// arguments, every other param, property, result and workspace has its environment
+// variable and StepEnvVar selects the entrypoint of the step
+func StepArgs(task *Task, step *Step, values StepValues) ([]string, []StepEnv) {
+	var args []string
+	var env []StepEnv
+
+	for _, param := range step.Params {
+		switch param.Type {
+		case TypeArray:
+			args = append(args, ArrayArgs(param, values.Array(param)...)...)
+		case TypeObject:
+			for _, property := range param.Properties {
+				if value, ok := values.Property(param, property); ok {
+					env = append(env, StepEnv{Name: property.EnvVar, Value: value})
+				}
+			}
+		default:
+			env = append(env, StepEnv{Name: param.EnvVar, Value: values.Param(param)})
+		}
+	}
+
+	for _, result := range step.Results {
+		env = append(env, StepEnv{Name: result.EnvVar, Value: values.Result(result)})
+	}
+
+	for _, workspace := range task.Workspaces {
+		env = append(env, StepEnv{Name: workspace.EnvVar, Value: values.Workspace(workspace)})
+	}
+
+	if step.Entrypoint != "" {
+		env = append(env, StepEnv{Name: StepEnvVar, Value: step.Name})
+	}
+
+	return args, env
+}
@@ -0,0 +1,786 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ir
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"log/slog"
+	"regexp"
+	"sort"
+	"strings"
+
+	ttmarkers "github.com/raskyld/go-tektasker/pkg/markers"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+// ErrInvalidTask is returned when the markers of a package do not describe a valid Task,
+// the diagnostics are reported as errors of the package itself
+var ErrInvalidTask = errors.New("invalid task")
+
+// Builder builds the Task of every package once and shares it between
+// the generators using the same Builder
+type Builder struct {
+	Logger *slog.Logger
+
+	tasks     map[*loader.Package]builtTask
+	pipelines map[*loader.Package]builtPipeline
+}
+
+type builtTask struct {
+	task *Task
+	err  error
+}
+
+type builtPipeline struct {
+	pipeline *Pipeline
+	err      error
+}
+
+func NewBuilder(logger *slog.Logger) *Builder {
+	return &Builder{
+		Logger:    logger.With("component", "ir"),
+		tasks:     make(map[*loader.Package]builtTask),
+		pipelines: make(map[*loader.Package]builtPipeline),
+	}
+}
+
+// Build returns the Task of the package or nil if the package is not a Task.
+// If the markers are not valid, ErrInvalidTask is returned and every diagnostic
+// is added, with its position, to the errors of the package.
+func (b *Builder) Build(collector *markers.Collector, pkg *loader.Package) (*Task, error) {
+	if built, ok := b.tasks[pkg]; ok {
+		return built.task, built.err
+	}
+
+	task, errs := b.build(collector, pkg)
+
+	var err error
+	if len(errs) > 0 {
+		pkg.AddError(loader.ErrList(errs))
+		task, err = nil, ErrInvalidTask
+	}
+
+	b.tasks[pkg] = builtTask{task: task, err: err}
+	return task, err
+}
+
+func (b *Builder) build(collector *markers.Collector, pkg *loader.Package) (*Task, []error) {
+	logger := b.Logger.With("pkg", pkg.Name)
+	logger.Debug("starting collecting")
+
+	// Markers which cannot be parsed are reported with their position
+	markersByNode, err := collector.MarkersInPackage(pkg)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	// Check the package-level task marker is present or skip
+	var taskMarker *ttmarkers.Task
+	var taskFile *ast.File
+	var errs []error
+
+	for _, file := range pkg.Syntax {
+		for _, rawTask := range markersByNode[file][ttmarkers.MarkerTask] {
+			if taskMarker != nil {
+				errs = append(errs, loader.ErrFromNode(
+					fmt.Errorf("task marker is already declared in %s", fileName(pkg, taskFile)),
+					file.Name))
+				continue
+			}
+
+			if marker, ok := rawTask.(ttmarkers.Task); ok {
+				taskMarker, taskFile = &marker, file
+			}
+		}
+	}
+
+	// A StepAction is built as a Task made of a single step
+	var stepAction bool
+	for _, file := range pkg.Syntax {
+		for _, rawStepAction := range markersByNode[file][ttmarkers.MarkerStepAction] {
+			if taskMarker != nil {
+				errs = append(errs, loader.ErrFromNode(
+					fmt.Errorf("task or stepaction marker is already declared in %s", fileName(pkg, taskFile)),
+					file.Name))
+				continue
+			}
+
+			if marker, ok := rawStepAction.(ttmarkers.StepAction); ok {
+				taskMarker, taskFile = &ttmarkers.Task{Name: marker.Name, Version: marker.Version}, file
+				stepAction = true
+			}
+		}
+	}
+
+	if taskMarker == nil {
+		// If no task marker is set on package, simply skip it
+		logger.Info("skipping non-task package")
+		return nil, errs
+	}
+
+	for _, file := range pkg.Syntax {
+		if len(markersByNode[file][ttmarkers.MarkerPipeline]) > 0 {
+			errs = append(errs, loader.ErrFromNode(errors.New("a package can not be both a task and a pipeline"), file.Name))
+		}
+
+		if !stepAction {
+			continue
+		}
+
+		// the Task referencing a StepAction owns these
+		for _, name := range []string{ttmarkers.MarkerWorkspace, ttmarkers.MarkerSidecar, ttmarkers.MarkerResources} {
+			if len(markersByNode[file][name]) > 0 {
+				errs = append(errs, loader.ErrFromNode(fmt.Errorf("%s marker can not be used in a stepaction", name), file.Name))
+			}
+		}
+	}
+
+	task := &Task{
+		Name:        taskMarker.Name,
+		Version:     taskMarker.Version,
+		Description: packageDoc(pkg),
+		StepAction:  stepAction,
+		Package:     pkg,
+		Pos:         taskFile.Package,
+
+		DisplayName:         taskMarker.DisplayName,
+		MinPipelinesVersion: taskMarker.MinPipelinesVersion,
+		Categories:          taskMarker.Categories,
+		Tags:                taskMarker.Tags,
+		Platforms:           taskMarker.Platforms,
+	}
+
+	workspaces, workspaceErrs := buildWorkspaces(logger, pkg, markersByNode)
+	task.Workspaces = workspaces
+	errs = append(errs, workspaceErrs...)
+
+	for _, file := range pkg.Syntax {
+		for _, rawSidecar := range markersByNode[file][ttmarkers.MarkerSidecar] {
+			if sidecar, isSidecar := rawSidecar.(ttmarkers.Sidecar); isSidecar {
+				logger.Info("found sidecar", "sidecar", sidecar.Name)
+
+				// ensure no duplication
+				if task.Sidecar(sidecar.Name) != nil {
+					errs = append(errs, loader.ErrFromNode(
+						fmt.Errorf("sidecar %q is declared more than once", sidecar.Name),
+						file.Name))
+					continue
+				}
+
+				if sidecar.ReadinessPort < 0 || sidecar.ReadinessPort > 65535 {
+					errs = append(errs, loader.ErrFromNode(
+						fmt.Errorf("readiness port %d of sidecar %q is not a valid port", sidecar.ReadinessPort, sidecar.Name),
+						file.Name))
+					continue
+				}
+
+				task.Sidecars = append(task.Sidecars, &Sidecar{
+					Name:          sidecar.Name,
+					Image:         sidecar.Image,
+					Command:       sidecar.Command,
+					ReadinessPort: sidecar.ReadinessPort,
+				})
+			}
+		}
+	}
+
+	var resources *Resources
+	var securityContext *SecurityContext
+	var hasResources, hasSecurityContext bool
+
+	for _, file := range pkg.Syntax {
+		for _, rawResources := range markersByNode[file][ttmarkers.MarkerResources] {
+			if marker, ok := rawResources.(ttmarkers.Resources); ok {
+				if hasResources {
+					errs = append(errs, loader.ErrFromNode(errors.New("resources marker is declared more than once"), file.Name))
+					continue
+				}
+
+				hasResources = true
+
+				resources, err = buildResources(marker)
+				if err != nil {
+					errs = append(errs, loader.ErrFromNode(err, file.Name))
+				}
+			}
+		}
+
+		for _, rawImage := range markersByNode[file][ttmarkers.MarkerImage] {
+			if marker, ok := rawImage.(ttmarkers.Image); ok {
+				if task.Image != nil {
+					errs = append(errs, loader.ErrFromNode(errors.New("image marker is declared more than once"), file.Name))
+					continue
+				}
+
+				task.Image, err = buildImage(marker)
+				if err != nil {
+					errs = append(errs, loader.ErrFromNode(err, file.Name))
+				}
+			}
+		}
+
+		for _, rawSecurityContext := range markersByNode[file][ttmarkers.MarkerSecurityContext] {
+			if marker, ok := rawSecurityContext.(ttmarkers.SecurityContext); ok {
+				if hasSecurityContext {
+					errs = append(errs, loader.ErrFromNode(errors.New("securityContext marker is declared more than once"), file.Name))
+					continue
+				}
+
+				hasSecurityContext = true
+
+				securityContext, err = buildSecurityContext(marker)
+				if err != nil {
+					errs = append(errs, loader.ErrFromNode(err, file.Name))
+				}
+			}
+		}
+	}
+
+	specs := typeSpecsOf(pkg)
+	err = markers.EachType(collector, pkg, func(info *markers.TypeInfo) {
+		rawParam := info.Markers.Get(ttmarkers.MarkerParam)
+		if rawParam != nil {
+			if param, ok := rawParam.(ttmarkers.Param); ok {
+				logger := logger.With("param", param.Name)
+				logger.Info("parameter found")
+
+				// ensure no duplication
+				if previous := task.Param(param.Name); previous != nil {
+					errs = append(errs, loader.ErrFromNode(
+						fmt.Errorf("parameter %q is already declared by type %s", param.Name, previous.GoType),
+						info.RawSpec))
+					return
+				}
+
+				builtParam, paramErrs := buildParam(param, info, specs)
+				if len(paramErrs) > 0 {
+					errs = append(errs, paramErrs...)
+					return
+				}
+
+				task.Params = append(task.Params, builtParam)
+			}
+		}
+
+		rawResult := info.Markers.Get(ttmarkers.MarkerResult)
+		if rawResult != nil {
+			if result, ok := rawResult.(ttmarkers.Result); ok {
+				logger := logger.With("result", result.Name)
+				logger.Info("result found")
+
+				// ensure no duplication
+				if previous := task.Result(result.Name); previous != nil {
+					errs = append(errs, loader.ErrFromNode(
+						fmt.Errorf("result %q is already declared by type %s", result.Name, previous.GoType),
+						info.RawSpec))
+					return
+				}
+
+				builtResult, resultErrs := buildResult(result, info, specs)
+				if len(resultErrs) > 0 {
+					errs = append(errs, resultErrs...)
+					return
+				}
+
+				task.Results = append(task.Results, builtResult)
+			}
+		}
+	})
+	if err != nil {
+		return nil, append(errs, err)
+	}
+
+	errs = append(errs, checkEnvVarCollisions(task)...)
+
+	errs = append(errs, buildSteps(task, collector.Registry)...)
+
+	if stepAction && (len(task.Steps) != 1 || task.Steps[0].Name != "") {
+		errs = append(errs, loader.ErrFromNode(
+			fmt.Errorf("%s marker can not be used in a stepaction", ttmarkers.MarkerStep), posNode(task.Pos)))
+	}
+
+	for _, step := range task.Steps {
+		step.Resources = resources
+		step.SecurityContext = securityContext
+	}
+
+	return task, errs
+}
+
+// buildWorkspaces builds the workspaces declared on the package
+func buildWorkspaces(logger *slog.Logger, pkg *loader.Package, markersByNode map[ast.Node]markers.MarkerValues) ([]*Workspace, []error) {
+	var workspaces []*Workspace
+	var errs []error
+	seen := make(map[string]struct{})
+
+	for _, file := range pkg.Syntax {
+		for _, rawWorkspace := range markersByNode[file][ttmarkers.MarkerWorkspace] {
+			if workspace, isWorkspace := rawWorkspace.(ttmarkers.Workspace); isWorkspace {
+				logger.Info("found workspace", "workspace", workspace.Name)
+
+				// ensure no duplication
+				if _, ok := seen[workspace.Name]; ok {
+					errs = append(errs, loader.ErrFromNode(
+						fmt.Errorf("workspace %q is declared more than once", workspace.Name),
+						file.Name))
+					continue
+				}
+
+				seen[workspace.Name] = struct{}{}
+				workspaces = append(workspaces, &Workspace{
+					Name:        workspace.Name,
+					Description: workspace.Description,
+					MountPath:   workspace.MountPath,
+					ReadOnly:    workspace.ReadOnly,
+					Optional:    workspace.Optional,
+					EnvVar:      WorkspaceEnvVar(workspace.Name),
+					Pos:         file.Name.Pos(),
+				})
+			}
+		}
+	}
+
+	return workspaces, errs
+}
+
+// fileName returns the path of a file of the package,
+// its FileSet can not be used as it is only set once the package is type-checked
+func fileName(pkg *loader.Package, file *ast.File) string {
+	for i, syntax := range pkg.Syntax {
+		if syntax == file && i < len(pkg.CompiledGoFiles) {
+			return pkg.CompiledGoFiles[i]
+		}
+	}
+
+	return pkg.PkgPath
+}
+
+// packageDoc concatenates every package-level GoDoc to populate a Task description
+func packageDoc(pkg *loader.Package) string {
+	packagesDoc := make([]string, 0, len(pkg.Syntax))
+	for _, file := range pkg.Syntax {
+		if file != nil && file.Doc != nil {
+			packagesDoc = append(packagesDoc, file.Doc.Text())
+		}
+	}
+
+	return strings.Join(packagesDoc, "\n")
+}
+
+func buildParam(param ttmarkers.Param, typeInfo *markers.TypeInfo, specs typeSpecs) (*Param, []error) {
+	rt := &Param{
+		Name:        param.Name,
+		GoType:      typeInfo.Name,
+		Description: typeInfo.Doc,
+		EnvVar:      ParamEnvVar(param.Name),
+		Pos:         typeInfo.RawSpec.Pos(),
+	}
+	rt.Encoding, rt.Scalar = encodingOf(typeInfo.RawSpec.Type, specs)
+
+	// First, we must figure out which Tekton type to use for the marked type
+	switch typ := typeInfo.RawSpec.Type.(type) {
+	case *ast.ArrayType:
+		if typ.Len != nil {
+			return nil, []error{loader.ErrFromNode(
+				fmt.Errorf("array parameter %q must be a slice", param.Name), typeInfo.RawSpec)}
+		}
+
+		rt.Type = TypeArray
+		// the generated code converts the elements to their scalar kind,
+		// so only the elements of a builtin type are recognised
+		rt.ItemEncoding, rt.ItemScalar = encodingOf(typ.Elt, nil)
+	case *ast.MapType:
+		// Should be a valid json string though as
+		// we don't have a non-strict json schema type in Tekton
+		rt.Type = TypeString
+	case *ast.StructType:
+		// If the struct is not in strict mode then it does not have
+		// a determinist schema
+		if !param.Strict {
+			rt.Type = TypeString
+			break
+		}
+
+		properties, errs := buildProperties(typeInfo)
+		if len(errs) > 0 {
+			return nil, errs
+		}
+
+		rt.Properties = properties
+		rt.Type = TypeObject
+	default:
+		rt.Type = TypeString
+	}
+
+	if rt.Type != TypeString {
+		// arrays are passed through the args of the step and objects key by key
+		if param.Env != "" {
+			return nil, []error{loader.ErrFromNode(
+				fmt.Errorf("env can not be set on %s parameter %q", rt.Type, param.Name),
+				typeInfo.RawSpec)}
+		}
+
+		rt.EnvVar = ""
+		for _, property := range rt.Properties {
+			property.EnvVar = ParamEnvVar(param.Name + "." + property.Name)
+		}
+	} else if param.Env != "" {
+		if err := checkEnvVar(param.Env); err != nil {
+			return nil, []error{loader.ErrFromNode(err, typeInfo.RawSpec)}
+		}
+
+		rt.EnvVar = param.Env
+	}
+
+	if len(param.Enum) > 0 || param.Pattern != "" {
+		if errs := restrictParam(rt, param); len(errs) > 0 {
+			for i := range errs {
+				errs[i] = loader.ErrFromNode(errs[i], typeInfo.RawSpec)
+			}
+
+			return nil, errs
+		}
+	}
+
+	if param.Default != nil {
+		defValue := *param.Default
+		switch rt.Type {
+		case TypeArray:
+			var defaultArray []interface{}
+			err := json.Unmarshal([]byte(defValue), &defaultArray)
+			if err != nil {
+				return nil, []error{loader.ErrFromNode(
+					fmt.Errorf("default of array parameter %q must be a JSON array: %w", param.Name, err),
+					typeInfo.RawSpec)}
+			}
+			rt.Default = defaultArray
+		case TypeObject:
+			// See TEP-0075 for how we should update and maintain this section
+			var object map[string]interface{}
+			err := json.Unmarshal([]byte(defValue), &object)
+			if err != nil {
+				return nil, []error{loader.ErrFromNode(
+					fmt.Errorf("default of object parameter %q must be a JSON object: %w", param.Name, err),
+					typeInfo.RawSpec)}
+			}
+
+			if err := completeObjectDefault(rt, object); err != nil {
+				return nil, []error{loader.ErrFromNode(err, typeInfo.RawSpec)}
+			}
+
+			rt.Default = object
+		default:
+			if rt.Encoding == EncodingScalar {
+				if err := ParseScalar(rt.Scalar, defValue); err != nil {
+					return nil, []error{loader.ErrFromNode(
+						fmt.Errorf("default of parameter %q must be a valid %s: %w", param.Name, rt.Scalar, err),
+						typeInfo.RawSpec)}
+				}
+			}
+
+			if err := rt.CheckValue(defValue); err != nil {
+				return nil, []error{loader.ErrFromNode(
+					fmt.Errorf("default of parameter %q is not allowed: %w", param.Name, err),
+					typeInfo.RawSpec)}
+			}
+
+			rt.Default = defValue
+		}
+	} else if rt.Type == TypeObject && !rt.HasRequiredProperty() {
+		// the object can be omitted when none of its properties is required
+		object := make(map[string]interface{}, len(rt.Properties))
+		_ = completeObjectDefault(rt, object)
+		rt.Default = object
+	}
+
+	return rt, nil
+}
+
+// completeObjectDefault sets the optional properties missing from the default of an object param
+// to an empty string, Tekton only accepts a default holding every property
+func completeObjectDefault(rt *Param, object map[string]interface{}) error {
+	for _, property := range rt.Properties {
+		if _, ok := object[property.Name]; ok {
+			continue
+		}
+
+		if property.Required {
+			return fmt.Errorf("default of object parameter %q is missing the required property %q", rt.Name, property.Name)
+		}
+
+		object[property.Name] = ""
+	}
+
+	return nil
+}
+
+// restrictParam sets the enum and the pattern of a param, they are only supported on
+// string params made of a string or of a builtin scalar as they are checked on the raw value
+func restrictParam(rt *Param, param ttmarkers.Param) []error {
+	if rt.Type != TypeString || (rt.Encoding != EncodingRaw && rt.Encoding != EncodingScalar) {
+		return []error{fmt.Errorf("enum and pattern of parameter %q are only supported on "+
+			"types made of a string or of a builtin scalar", param.Name)}
+	}
+
+	var errs []error
+	seen := make(map[string]struct{}, len(param.Enum))
+	for _, value := range param.Enum {
+		if _, duplicate := seen[value]; duplicate {
+			errs = append(errs, fmt.Errorf("value %q is repeated in the enum of parameter %q", value, param.Name))
+			continue
+		}
+
+		seen[value] = struct{}{}
+
+		if rt.Encoding == EncodingScalar {
+			if err := ParseScalar(rt.Scalar, value); err != nil {
+				errs = append(errs, fmt.Errorf("value %q of the enum of parameter %q must be a valid %s: %w",
+					value, param.Name, rt.Scalar, err))
+			}
+		}
+	}
+
+	if param.Pattern != "" {
+		if _, err := regexp.Compile(param.Pattern); err != nil {
+			errs = append(errs, fmt.Errorf("pattern of parameter %q is not a valid regular expression: %w",
+				param.Name, err))
+		}
+	}
+
+	rt.Enum = param.Enum
+	rt.Pattern = param.Pattern
+
+	return errs
+}
+
+// CheckValue returns an error if the value is not in the enum of the param
+// or does not match its pattern
+func (p *Param) CheckValue(value string) error {
+	if len(p.Enum) > 0 {
+		allowed := false
+		for _, enumValue := range p.Enum {
+			if value == enumValue {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return fmt.Errorf("%q is not one of %s", value, strings.Join(p.Enum, ", "))
+		}
+	}
+
+	if p.Pattern != "" && !regexp.MustCompile(p.Pattern).MatchString(value) {
+		return fmt.Errorf("%q does not match %s", value, p.Pattern)
+	}
+
+	return nil
+}
+
+// buildProperties computes the keys of an object from the JSON tags of a strict struct
+func buildProperties(typeInfo *markers.TypeInfo) ([]*Property, []error) {
+	properties := make([]*Property, 0, len(typeInfo.Fields))
+	seen := make(map[string]struct{})
+	var errs []error
+
+	for _, field := range typeInfo.Fields {
+		tag, hasTag := field.Tag.Lookup("json")
+		if !hasTag {
+			errs = append(errs, loader.ErrFromNode(
+				fmt.Errorf("missing json tag on field %s of strict struct %s", field.Name, typeInfo.Name),
+				field.RawField))
+			continue
+		}
+
+		tags := strings.Split(tag, ",")
+		if len(tags[0]) == 0 {
+			errs = append(errs, loader.ErrFromNode(
+				fmt.Errorf("field %s of strict struct %s needs an explicit json tag name", field.Name, typeInfo.Name),
+				field.RawField))
+			continue
+		}
+
+		if strings.HasPrefix(tags[0], "-") {
+			// Ignore this field
+			continue
+		}
+
+		if _, ok := seen[tags[0]]; ok {
+			errs = append(errs, loader.ErrFromNode(
+				fmt.Errorf("json tag name %q is used more than once in strict struct %s", tags[0], typeInfo.Name),
+				field.RawField))
+			continue
+		}
+
+		seen[tags[0]] = struct{}{}
+
+		if field.Name == "" {
+			errs = append(errs, loader.ErrFromNode(
+				fmt.Errorf("embedded field of strict struct %s can not be a property", typeInfo.Name),
+				field.RawField))
+			continue
+		}
+
+		propertyType, err := propertyTypeOf(field)
+		if err != nil {
+			errs = append(errs, loader.ErrFromNode(
+				fmt.Errorf("field %s of strict struct %s: %w", field.Name, typeInfo.Name, err),
+				field.RawField))
+			continue
+		}
+
+		required := true
+		for _, option := range tags[1:] {
+			if option == "omitempty" {
+				required = false
+			}
+		}
+
+		properties = append(properties, &Property{
+			Name:     tags[0],
+			Field:    field.Name,
+			Type:     propertyType,
+			Required: required,
+			Pos:      field.RawField.Pos(),
+		})
+	}
+
+	sort.Slice(properties, func(i, j int) bool {
+		return properties[i].Name < properties[j].Name
+	})
+
+	return properties, errs
+}
+
+// propertyTypeOf figures out the Tekton type of an object key from the Go field type
+func propertyTypeOf(field markers.FieldInfo) (TektonType, error) {
+	// NB(raskyld): Tekton only supports string properties for now, see TEP-0075
+	if ident, ok := field.RawField.Type.(*ast.Ident); ok && ident.Name == "string" {
+		return TypeString, nil
+	}
+
+	return "", fmt.Errorf("type %s is not supported, object properties must be strings",
+		types.ExprString(field.RawField.Type))
+}
+
+func buildResult(result ttmarkers.Result, typeInfo *markers.TypeInfo, specs typeSpecs) (*Result, []error) {
+	rt := &Result{
+		Name:        result.Name,
+		GoType:      typeInfo.Name,
+		Description: typeInfo.Doc,
+		EnvVar:      ResultEnvVar(result.Name),
+		Pos:         typeInfo.RawSpec.Pos(),
+	}
+	rt.Encoding, rt.Scalar = encodingOf(typeInfo.RawSpec.Type, specs)
+
+	if result.Env != "" {
+		if err := checkEnvVar(result.Env); err != nil {
+			return nil, []error{loader.ErrFromNode(err, typeInfo.RawSpec)}
+		}
+
+		rt.EnvVar = result.Env
+	}
+
+	// First, we must figure out which Tekton type to use for the marked type
+	switch typeInfo.RawSpec.Type.(type) {
+	case *ast.ArrayType:
+		rt.Type = TypeArray
+	case *ast.StructType:
+		if !result.Strict {
+			rt.Type = TypeString
+			break
+		}
+
+		properties, errs := buildProperties(typeInfo)
+		if len(errs) > 0 {
+			return nil, errs
+		}
+
+		rt.Properties = properties
+		rt.Type = TypeObject
+	default:
+		if result.Strict {
+			return nil, []error{loader.ErrFromNode(
+				fmt.Errorf("strict result %q must be a struct", result.Name), typeInfo.RawSpec)}
+		}
+
+		rt.Type = TypeString
+	}
+
+	return rt, nil
+}
+
+// typeSpecs maps the name of the types declared in a package to their definition
+type typeSpecs map[string]ast.Expr
+
+// typeSpecsOf collects the types declared at the top-level of the package
+func typeSpecsOf(pkg *loader.Package) typeSpecs {
+	specs := make(typeSpecs)
+	for _, file := range pkg.Syntax {
+		for _, decl := range file.Decls {
+			genDecl, ok := decl.(*ast.GenDecl)
+			if !ok || genDecl.Tok != token.TYPE {
+				continue
+			}
+
+			for _, spec := range genDecl.Specs {
+				if typeSpec, ok := spec.(*ast.TypeSpec); ok {
+					specs[typeSpec.Name.Name] = typeSpec.Type
+				}
+			}
+		}
+	}
+
+	return specs
+}
+
+// encodingOf figures out how a value of the given type is represented as a string,
+// scalar is the builtin scalar kind of the type when it is encoded with EncodingScalar
+func encodingOf(typ ast.Expr, specs typeSpecs) (encoding Encoding, scalar string) {
+	// NB(raskyld): this is for ease of use when we have a type made of string
+	// or of a builtin scalar, otherwise, we just expect the value to be valid JSON.
+	// Without type-checking, the types defined from another type of the package
+	// are followed down to a builtin but the ones of other packages are not,
+	// e.g. a type defined from a type of another package is encoded in JSON.
+	seen := make(map[string]bool)
+	for {
+		kind := types.ExprString(typ)
+		if kind == "string" {
+			return EncodingRaw, ""
+		}
+
+		if _, ok := ScalarBitSize(kind); ok {
+			return EncodingScalar, kind
+		}
+
+		ident, ok := typ.(*ast.Ident)
+		if !ok || specs[ident.Name] == nil || seen[ident.Name] {
+			break
+		}
+
+		seen[ident.Name] = true
+		typ = specs[ident.Name]
+	}
+
+	return EncodingJSON, ""
+}
@@ -0,0 +1,383 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ir
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	ttmarkers "github.com/raskyld/go-tektasker/pkg/markers"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+// testBuild loads the packages of testdata/<dir>/... and returns a Builder
+// along with the collector and the loaded packages, sorted by import path
+func testBuild(t *testing.T, dir string) (*Builder, *markers.Collector, []*loader.Package) {
+	t.Helper()
+
+	roots, err := loader.LoadRoots("./testdata/" + dir + "/...")
+	if err != nil {
+		t.Fatalf("couldnt load testdata/%s: %s", dir, err.Error())
+	}
+
+	registry := &markers.Registry{}
+	if err := ttmarkers.Register(registry); err != nil {
+		t.Fatalf("couldnt register markers: %s", err.Error())
+	}
+
+	sort.Slice(roots, func(i, j int) bool {
+		return roots[i].PkgPath < roots[j].PkgPath
+	})
+
+	return NewBuilder(slog.New(slog.NewTextHandler(io.Discard, nil))), &markers.Collector{Registry: registry}, roots
+}
+
+// errorsOf returns the errors of the package as "<file>:<line>: <message>"
+func errorsOf(pkg *loader.Package) []string {
+	var errs []string
+	for _, err := range pkg.Errors {
+		pos := filepath.Base(err.Pos)
+		if parts := strings.Split(pos, ":"); len(parts) > 2 {
+			pos = strings.Join(parts[:2], ":")
+		}
+
+		errs = append(errs, pos+": "+err.Msg)
+	}
+
+	return errs
+}
+
+func TestBuildDuplicateTask(t *testing.T) {
+	builder, collector, roots := testBuild(t, "duptask")
+
+	task, err := builder.Build(collector, roots[0])
+	if !errors.Is(err, ErrInvalidTask) || task != nil {
+		t.Fatalf("Build() = %v, %v, wanted ErrInvalidTask", task, err)
+	}
+
+	wanted := []string{
+		"b.go:3: task marker is already declared in " + roots[0].CompiledGoFiles[0],
+	}
+
+	if got := errorsOf(roots[0]); !reflect.DeepEqual(got, wanted) {
+		t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", got, wanted)
+	}
+}
+
+func TestBuildSteps(t *testing.T) {
+	builder, collector, roots := testBuild(t, "steps")
+
+	tests := []struct {
+		name    string
+		pkg     *loader.Package
+		wantErr []string
+		result  []Step
+	}{
+		{
+			"Malformed step marker",
+			roots[0],
+			[]string{
+				`main.go:7: unknown argument "unknown" (at <input>:1:19)`,
+				`main.go:7: extra arguments provided: "true" (at <input>:1:20)`,
+			},
+			nil,
+		},
+		{
+			"Missing entrypoint",
+			roots[1],
+			[]string{
+				`main.go:2: entrypoint build of step "build" is not a function of the package`,
+			},
+			nil,
+		},
+		{
+			"Several steps",
+			roots[2],
+			nil,
+			[]Step{
+				{Name: "prepare", Image: "alpine", Command: []string{"true"}},
+				{Name: "build", Entrypoint: "build"},
+				{Name: "test", Entrypoint: "test"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			task, err := builder.Build(collector, test.pkg)
+			if (err != nil) != (test.wantErr != nil) {
+				t.Fatalf("Build() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if got := errorsOf(test.pkg); !reflect.DeepEqual(got, test.wantErr) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", got, test.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			var result []Step
+			for _, step := range task.Steps {
+				result = append(result, Step{
+					Name:       step.Name,
+					Entrypoint: step.Entrypoint,
+					Image:      step.Image,
+					Command:    step.Command,
+				})
+			}
+
+			if !reflect.DeepEqual(result, test.result) {
+				t.Errorf("unwanted diff, got\n---\n%v\n---\nwanted\n---\n%v", result, test.result)
+			}
+		})
+	}
+}
+
+func TestBuildSidecars(t *testing.T) {
+	builder, collector, roots := testBuild(t, "sidecars")
+
+	tests := []struct {
+		name    string
+		pkg     *loader.Package
+		wantErr []string
+		result  []*Sidecar
+	}{
+		{
+			"Duplicate sidecar",
+			roots[0],
+			[]string{
+				`main.go:5: sidecar "redis" is declared more than once`,
+			},
+			nil,
+		},
+		{
+			"Invalid readiness port",
+			roots[1],
+			[]string{
+				`main.go:4: readiness port 70000 of sidecar "redis" is not a valid port`,
+			},
+			nil,
+		},
+		{
+			"Several sidecars",
+			roots[2],
+			nil,
+			[]*Sidecar{
+				{Name: "redis", Image: "redis:7", Command: []string{"redis-server", "--save"}, ReadinessPort: 6379},
+				{Name: "registry", Image: "registry:2"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			task, err := builder.Build(collector, test.pkg)
+			if (err != nil) != (test.wantErr != nil) {
+				t.Fatalf("Build() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if got := errorsOf(test.pkg); !reflect.DeepEqual(got, test.wantErr) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", got, test.wantErr)
+			}
+
+			if err != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(task.Sidecars, test.result) {
+				t.Errorf("unwanted diff, got\n---\n%v\n---\nwanted\n---\n%v", task.Sidecars, test.result)
+			}
+		})
+	}
+}
+
+func TestBuildObjectDefaults(t *testing.T) {
+	builder, collector, roots := testBuild(t, "objects")
+
+	task, err := builder.Build(collector, roots[0])
+	if err != nil {
+		t.Fatalf("Build() error = %v, errors %v", err, errorsOf(roots[0]))
+	}
+
+	tests := []struct {
+		name   string
+		param  string
+		result interface{}
+	}{
+		{
+			"Every property is optional",
+			"optional",
+			map[string]interface{}{"revision": "", "url": ""},
+		},
+		{
+			"A property is required",
+			"mixed",
+			nil,
+		},
+		{
+			"Default without the optional properties",
+			"completed",
+			map[string]interface{}{"revision": "", "url": "https://example.com"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := task.Param(test.param).Default
+			if !reflect.DeepEqual(result, test.result) {
+				t.Errorf("unwanted diff, got\n---\n%v\n---\nwanted\n---\n%v", result, test.result)
+			}
+		})
+	}
+
+	task, err = builder.Build(collector, roots[1])
+	wantErr := []string{`main.go:8: default of object parameter "repo" is missing the required property "url"`}
+	if !errors.Is(err, ErrInvalidTask) || !reflect.DeepEqual(errorsOf(roots[1]), wantErr) {
+		t.Errorf("unwanted diff, got\n---\n%v %s\n---\nwanted\n---\n%s", task, errorsOf(roots[1]), wantErr)
+	}
+}
+
+func TestBuildScalars(t *testing.T) {
+	builder, collector, roots := testBuild(t, "scalars")
+
+	task, err := builder.Build(collector, roots[0])
+	if err != nil {
+		t.Fatalf("Build() error = %v, errors %v", err, errorsOf(roots[0]))
+	}
+
+	tests := []struct {
+		name     string
+		encoding Encoding
+		scalar   string
+		result   bool
+	}{
+		{"mode", EncodingScalar, "byte", false},
+		{"separator", EncodingScalar, "rune", false},
+		{"retries", EncodingScalar, "int32", false},
+		{"timeout", EncodingScalar, ScalarDuration, false},
+		{"deadline", EncodingJSON, "", false},
+		{"attempts", EncodingScalar, "int32", true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var encoding Encoding
+			var scalar string
+			if test.result {
+				encoding, scalar = task.Result(test.name).Encoding, task.Result(test.name).Scalar
+			} else {
+				encoding, scalar = task.Param(test.name).Encoding, task.Param(test.name).Scalar
+			}
+
+			if encoding != test.encoding || scalar != test.scalar {
+				t.Errorf("unwanted diff, got\n---\n%s %s\n---\nwanted\n---\n%s %s", encoding, scalar, test.encoding, test.scalar)
+			}
+		})
+	}
+
+	// the elements are converted to their scalar kind by the generated code,
+	// so the ones of a type defined in the package are encoded in JSON
+	if counts := task.Param("counts"); counts.ItemEncoding != EncodingJSON || counts.ItemScalar != "" {
+		t.Errorf("unwanted diff, got\n---\n%s %s\n---\nwanted\n---\n%s", counts.ItemEncoding, counts.ItemScalar, EncodingJSON)
+	}
+}
+
+func TestBuildDuplicatePipeline(t *testing.T) {
+	builder, collector, roots := testBuild(t, "duppipeline")
+
+	pipeline, err := builder.BuildPipeline(collector, roots[0], roots)
+	if !errors.Is(err, ErrInvalidPipeline) || pipeline != nil {
+		t.Fatalf("BuildPipeline() = %v, %v, wanted ErrInvalidPipeline", pipeline, err)
+	}
+
+	wanted := []string{
+		"b.go:3: pipeline marker is already declared in " + roots[0].CompiledGoFiles[0],
+	}
+
+	if got := errorsOf(roots[0]); !reflect.DeepEqual(got, wanted) {
+		t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", got, wanted)
+	}
+}
+
+func TestBuildPipelineReferences(t *testing.T) {
+	builder, collector, roots := testBuild(t, "pipeline")
+
+	pipeline, err := builder.BuildPipeline(collector, roots[1], roots)
+	if !errors.Is(err, ErrInvalidPipeline) || pipeline != nil {
+		t.Fatalf("BuildPipeline() = %v, %v, wanted ErrInvalidPipeline", pipeline, err)
+	}
+
+	wanted := []string{
+		`main.go:11: task "unknown-task": package "github.com/raskyld/go-tektasker/internal/ir/testdata/pipeline/missing" is not part of the generation, add it to the input packages`,
+		`main.go:12: task "unknown-result": param "who": task greet has no result "nope"`,
+		`main.go:13: task "unknown-param": param "who": pipeline has no param "nope"`,
+		`main.go:14: task "unknown-refs": runAfter references unknown task "ghost"`,
+		`main.go:14: task "unknown-refs": task greet has no param "extra"`,
+		`main.go:14: task "unknown-refs": param "who": reference to unknown task "ghost"`,
+	}
+
+	if got := errorsOf(roots[1]); !reflect.DeepEqual(got, wanted) {
+		t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", strings.Join(got, "\n"), strings.Join(wanted, "\n"))
+	}
+}
+
+func TestBuildPipeline(t *testing.T) {
+	builder, collector, roots := testBuild(t, "pipeline")
+
+	pipeline, err := builder.BuildPipeline(collector, roots[2], roots)
+	if err != nil {
+		t.Fatalf("BuildPipeline() error = %v, errors %v", err, errorsOf(roots[2]))
+	}
+
+	type pipelineTask struct {
+		Name     string
+		Task     string
+		RunAfter []string
+		Params   []ParamValue
+	}
+
+	var result []pipelineTask
+	for _, task := range pipeline.Tasks {
+		var params []ParamValue
+		for _, param := range task.Params {
+			params = append(params, *param)
+		}
+
+		result = append(result, pipelineTask{task.Name, task.Task.Name, task.RunAfter, params})
+	}
+
+	wanted := []pipelineTask{
+		{"first", "greet", nil, []ParamValue{{"who", "$(params.name)"}}},
+		{"second", "greet", []string{"first"}, []ParamValue{{"who", "$(tasks.first.results.greeting)"}}},
+	}
+
+	if !reflect.DeepEqual(result, wanted) {
+		t.Errorf("unwanted diff, got\n---\n%+v\n---\nwanted\n---\n%+v", result, wanted)
+	}
+
+	if pipeline.Name != "valid" || pipeline.Description != "Package main greets twice.\n" || pipeline.Param("name") == nil {
+		t.Errorf("unwanted pipeline %s (%q) with params %v", pipeline.Name, pipeline.Description, pipeline.Params)
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ir
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	ttmarkers "github.com/raskyld/go-tektasker/pkg/markers"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// Validate checks the image has what its strategy needs
+func (i *Image) Validate() error {
+	switch i.Strategy {
+	case ImageStrategyKo:
+		if i.Reference != "" {
+			return errors.New("the reference of the ko image strategy is computed by ko, it can not be set")
+		}
+	case ImageStrategyFixed:
+		if !strings.Contains(i.Reference, "@sha256:") {
+			return fmt.Errorf("the reference %q of the fixed image strategy must be pinned by digest, e.g. registry.example.com/task@sha256:<digest>", i.Reference)
+		}
+	case ImageStrategyTemplate:
+		if i.Reference == "" {
+			return errors.New("the template image strategy needs the template of the reference")
+		}
+	default:
+		return fmt.Errorf("unknown image strategy %q, use one of %s, %s or %s",
+			i.Strategy, ImageStrategyKo, ImageStrategyFixed, ImageStrategyTemplate)
+	}
+
+	return nil
+}
+
+// buildImage validates the image marker
+func buildImage(marker ttmarkers.Image) (*Image, error) {
+	rt := &Image{
+		Strategy:  marker.Strategy,
+		Reference: marker.Reference,
+		Command:   marker.Command,
+	}
+
+	return rt, rt.Validate()
+}
+
+// buildResources validates the quantities of the resources marker
+func buildResources(marker ttmarkers.Resources) (*Resources, error) {
+	rt := &Resources{
+		Requests: make(map[string]string),
+		Limits:   make(map[string]string),
+	}
+
+	for _, resourceName := range []string{"cpu", "memory"} {
+		var request, limit string
+		switch resourceName {
+		case "cpu":
+			request, limit = marker.CPURequest, marker.CPULimit
+		case "memory":
+			request, limit = marker.MemoryRequest, marker.MemoryLimit
+		}
+
+		var requestQuantity, limitQuantity resource.Quantity
+		var err error
+
+		if request != "" {
+			requestQuantity, err = resource.ParseQuantity(request)
+			if err != nil {
+				return nil, fmt.Errorf("%s request %q is not a valid quantity: %w", resourceName, request, err)
+			}
+
+			rt.Requests[resourceName] = request
+		}
+
+		if limit != "" {
+			limitQuantity, err = resource.ParseQuantity(limit)
+			if err != nil {
+				return nil, fmt.Errorf("%s limit %q is not a valid quantity: %w", resourceName, limit, err)
+			}
+
+			rt.Limits[resourceName] = limit
+		}
+
+		if request != "" && limit != "" && requestQuantity.Cmp(limitQuantity) > 0 {
+			return nil, fmt.Errorf("%s request %s is greater than its limit %s", resourceName, request, limit)
+		}
+	}
+
+	return rt, nil
+}
+
+// buildSecurityContext validates the security context marker
+func buildSecurityContext(marker ttmarkers.SecurityContext) (*SecurityContext, error) {
+	rt := &SecurityContext{
+		RunAsNonRoot:           marker.RunAsNonRoot,
+		ReadOnlyRootFilesystem: marker.ReadOnlyRootFilesystem,
+		AddCapabilities:        marker.AddCapabilities,
+		DropCapabilities:       marker.DropCapabilities,
+	}
+
+	if marker.RunAsUser != nil {
+		if *marker.RunAsUser < 0 {
+			return nil, fmt.Errorf("runAsUser %d is not a valid UID", *marker.RunAsUser)
+		}
+
+		uid := int64(*marker.RunAsUser)
+		rt.RunAsUser = &uid
+	}
+
+	if rt.RunAsNonRoot != nil && *rt.RunAsNonRoot && rt.RunAsUser != nil && *rt.RunAsUser == 0 {
+		return nil, errors.New("runAsNonRoot can not be used with runAsUser=0")
+	}
+
+	return rt, nil
+}
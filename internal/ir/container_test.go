@@ -0,0 +1,163 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ir
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	ttmarkers "github.com/raskyld/go-tektasker/pkg/markers"
+)
+
+func TestBuildResources(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    ttmarkers.Resources
+		wantErr string
+		result  *Resources
+	}{
+		{
+			"No resources",
+			ttmarkers.Resources{},
+			"",
+			&Resources{Requests: map[string]string{}, Limits: map[string]string{}},
+		},
+		{
+			"Requests and limits",
+			ttmarkers.Resources{CPURequest: "100m", CPULimit: "1", MemoryRequest: "128Mi", MemoryLimit: "128Mi"},
+			"",
+			&Resources{
+				Requests: map[string]string{"cpu": "100m", "memory": "128Mi"},
+				Limits:   map[string]string{"cpu": "1", "memory": "128Mi"},
+			},
+		},
+		{
+			"Limit only",
+			ttmarkers.Resources{MemoryLimit: "1Gi"},
+			"",
+			&Resources{Requests: map[string]string{}, Limits: map[string]string{"memory": "1Gi"}},
+		},
+		{
+			"Invalid request",
+			ttmarkers.Resources{CPURequest: "a lot"},
+			`cpu request "a lot" is not a valid quantity`,
+			nil,
+		},
+		{
+			"Invalid limit",
+			ttmarkers.Resources{MemoryLimit: "128MB"},
+			`memory limit "128MB" is not a valid quantity`,
+			nil,
+		},
+		{
+			"Request greater than its limit",
+			ttmarkers.Resources{CPURequest: "1500m", CPULimit: "1"},
+			"cpu request 1500m is greater than its limit 1",
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := buildResources(test.args)
+			if (err != nil) != (test.wantErr != "") || (err != nil && !strings.HasPrefix(err.Error(), test.wantErr)) {
+				t.Fatalf("buildResources() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(result, test.result) {
+				t.Errorf("unwanted diff, got\n---\n%v\n---\nwanted\n---\n%v", result, test.result)
+			}
+		})
+	}
+}
+
+func TestBuildSecurityContext(t *testing.T) {
+	yes := true
+	root, user, invalid := 0, 1000, -1
+	rootUID, userUID := int64(0), int64(1000)
+
+	tests := []struct {
+		name    string
+		args    ttmarkers.SecurityContext
+		wantErr string
+		result  *SecurityContext
+	}{
+		{
+			"Non-root user",
+			ttmarkers.SecurityContext{RunAsNonRoot: &yes, RunAsUser: &user, DropCapabilities: []string{"ALL"}},
+			"",
+			&SecurityContext{RunAsNonRoot: &yes, RunAsUser: &userUID, DropCapabilities: []string{"ALL"}},
+		},
+		{
+			"Root user",
+			ttmarkers.SecurityContext{RunAsUser: &root, AddCapabilities: []string{"NET_ADMIN"}},
+			"",
+			&SecurityContext{RunAsUser: &rootUID, AddCapabilities: []string{"NET_ADMIN"}},
+		},
+		{
+			"Invalid UID",
+			ttmarkers.SecurityContext{RunAsUser: &invalid},
+			"runAsUser -1 is not a valid UID",
+			nil,
+		},
+		{
+			"Non-root with the root UID",
+			ttmarkers.SecurityContext{RunAsNonRoot: &yes, RunAsUser: &root},
+			"runAsNonRoot can not be used with runAsUser=0",
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := buildSecurityContext(test.args)
+			if (err != nil) != (test.wantErr != "") || (err != nil && err.Error() != test.wantErr) {
+				t.Fatalf("buildSecurityContext() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(result, test.result) {
+				t.Errorf("unwanted diff, got\n---\n%v\n---\nwanted\n---\n%v", result, test.result)
+			}
+		})
+	}
+}
+
+func TestImageValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    Image
+		wantErr bool
+	}{
+		{"Ko", Image{Strategy: ImageStrategyKo}, false},
+		{"Ko with a command", Image{Strategy: ImageStrategyKo, Command: []string{"/ko-app/hello"}}, false},
+		{"Ko with a reference", Image{Strategy: ImageStrategyKo, Reference: "alpine"}, true},
+		{"Fixed pinned by digest", Image{Strategy: ImageStrategyFixed, Reference: "alpine@sha256:0"}, false},
+		{"Fixed with a tag", Image{Strategy: ImageStrategyFixed, Reference: "alpine:3"}, true},
+		{"Template", Image{Strategy: ImageStrategyTemplate, Reference: "registry.example.com/{{.TaskName}}"}, false},
+		{"Template without reference", Image{Strategy: ImageStrategyTemplate}, true},
+		{"Unknown strategy", Image{Strategy: "docker"}, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.args.Validate(); (err != nil) != test.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ir
+
+import (
+	"fmt"
+	"go/token"
+	"regexp"
+	"strings"
+
+	"sigs.k8s.io/controller-tools/pkg/loader"
+)
+
+// validEnvVar is what most shells accept as a variable name
+var validEnvVar = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SanitizeEnvVar turns a param or result name into a fragment of environment variable name:
+// the name is upper-cased and every character which is not an ASCII letter, a digit
+// or an underscore is replaced by an underscore, e.g. `git-url` and `image.tag`
+// become `GIT_URL` and `IMAGE_TAG`
+func SanitizeEnvVar(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// ParamEnvVar is the default environment variable holding the value of a param
+func ParamEnvVar(name string) string {
+	return "PARAM_" + SanitizeEnvVar(name) + "_VALUE"
+}
+
+// ResultEnvVar is the default environment variable holding the path of a result
+func ResultEnvVar(name string) string {
+	return "RESULT_" + SanitizeEnvVar(name) + "_PATH"
+}
+
+// WorkspaceEnvVar is the environment variable holding the path of a workspace
+func WorkspaceEnvVar(name string) string {
+	return "WORKSPACE_" + SanitizeEnvVar(name) + "_PATH"
+}
+
+// checkEnvVar ensures an explicit environment variable name is usable
+func checkEnvVar(envVar string) error {
+	if !validEnvVar.MatchString(envVar) {
+		return fmt.Errorf("%q is not a valid environment variable name", envVar)
+	}
+
+	if envVar == StepEnvVar {
+		return fmt.Errorf("%s is reserved by tektasker", envVar)
+	}
+
+	return nil
+}
+
+// checkEnvVarCollisions ensures two params, results or workspaces of the Task do not share
+// the same environment variable once sanitized
+func checkEnvVarCollisions(task *Task) []error {
+	var errs []error
+	owners := make(map[string]string)
+
+	check := func(envVar, owner string, node loader.Node) {
+		if previous, collide := owners[envVar]; collide {
+			errs = append(errs, loader.ErrFromNode(
+				fmt.Errorf("%s and %s both use the environment variable %s, use the env option to rename one of them",
+					previous, owner, envVar),
+				node))
+			return
+		}
+
+		owners[envVar] = owner
+	}
+
+	for _, param := range task.Params {
+		for _, property := range param.Properties {
+			check(property.EnvVar, fmt.Sprintf("property %q of parameter %q", property.Name, param.Name),
+				posNode(property.Pos))
+		}
+
+		if param.EnvVar == "" {
+			continue
+		}
+
+		check(param.EnvVar, fmt.Sprintf("parameter %q", param.Name), posNode(param.Pos))
+	}
+
+	for _, result := range task.Results {
+		check(result.EnvVar, fmt.Sprintf("result %q", result.Name), posNode(result.Pos))
+	}
+
+	for _, workspace := range task.Workspaces {
+		check(workspace.EnvVar, fmt.Sprintf("workspace %q", workspace.Name), posNode(workspace.Pos))
+	}
+
+	return errs
+}
+
+// posNode makes a token.Pos a loader.Node
+type posNode token.Pos
+
+func (p posNode) Pos() token.Pos {
+	return token.Pos(p)
+}
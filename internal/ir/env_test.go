@@ -0,0 +1,121 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ir
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSanitizeEnvVar(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   string
+		result string
+	}{
+		{"Lower case", "url", "URL"},
+		{"Dashes", "git-url", "GIT_URL"},
+		{"Dots", "image.tag", "IMAGE_TAG"},
+		{"Digits and underscores", "step_2", "STEP_2"},
+		{"Upper case", "TLS", "TLS"},
+		{"Non-ASCII letters", "café", "CAF_"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := SanitizeEnvVar(test.args)
+			if result != test.result {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", result, test.result)
+			}
+		})
+	}
+}
+
+func TestCheckEnvVarCollisions(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   *Task
+		result []string
+	}{
+		{
+			"No collision",
+			&Task{
+				Params:     []*Param{{Name: "git-url", EnvVar: ParamEnvVar("git-url")}},
+				Results:    []*Result{{Name: "git-url", EnvVar: ResultEnvVar("git-url")}},
+				Workspaces: []*Workspace{{Name: "git-url", EnvVar: WorkspaceEnvVar("git-url")}},
+			},
+			nil,
+		},
+		{
+			"Params sanitized the same way",
+			&Task{
+				Params: []*Param{
+					{Name: "git-url", EnvVar: ParamEnvVar("git-url")},
+					{Name: "git.url", EnvVar: ParamEnvVar("git.url")},
+				},
+			},
+			[]string{
+				`parameter "git-url" and parameter "git.url" both use the environment variable PARAM_GIT_URL_VALUE, use the env option to rename one of them`,
+			},
+		},
+		{
+			"Param renamed like a result",
+			&Task{
+				Params:  []*Param{{Name: "out", EnvVar: "RESULT_OUT_PATH"}},
+				Results: []*Result{{Name: "out", EnvVar: ResultEnvVar("out")}},
+			},
+			[]string{
+				`parameter "out" and result "out" both use the environment variable RESULT_OUT_PATH, use the env option to rename one of them`,
+			},
+		},
+		{
+			"Result renamed like a workspace",
+			&Task{
+				Results:    []*Result{{Name: "source", EnvVar: "WORKSPACE_SOURCE_PATH"}},
+				Workspaces: []*Workspace{{Name: "source", EnvVar: WorkspaceEnvVar("source")}},
+			},
+			[]string{
+				`result "source" and workspace "source" both use the environment variable WORKSPACE_SOURCE_PATH, use the env option to rename one of them`,
+			},
+		},
+		{
+			"Property renamed like a param",
+			&Task{
+				Params: []*Param{
+					{Name: "conf", Properties: []*Property{{Name: "url", EnvVar: "PARAM_URL_VALUE"}}},
+					{Name: "url", EnvVar: ParamEnvVar("url")},
+				},
+			},
+			[]string{
+				`property "url" of parameter "conf" and parameter "url" both use the environment variable PARAM_URL_VALUE, use the env option to rename one of them`,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var result []string
+			for _, err := range checkEnvVarCollisions(test.args) {
+				result = append(result, err.Error())
+			}
+
+			if !reflect.DeepEqual(result, test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", result, test.result)
+			}
+		})
+	}
+}
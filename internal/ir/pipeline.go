@@ -0,0 +1,544 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ir
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	ttmarkers "github.com/raskyld/go-tektasker/pkg/markers"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+// ErrInvalidPipeline is returned when the markers of a package do not describe a valid Pipeline,
+// the diagnostics are reported as errors of the package itself
+var ErrInvalidPipeline = errors.New("invalid pipeline")
+
+// Pipeline is the intermediate representation of a Tekton Pipeline built from
+// the markers of a single Go package
+type Pipeline struct {
+	// Name is the name of the Pipeline manifest
+	Name string
+
+	// Version of the Pipeline as communicated to the users
+	Version string
+
+	// Description is built from the package-level GoDoc
+	Description string
+
+	// Package is the Go package the Pipeline is built from
+	Package *loader.Package
+
+	Params     []*Param
+	Workspaces []*Workspace
+	Tasks      []*PipelineTask
+}
+
+// PipelineTask is a Task run by a Pipeline
+type PipelineTask struct {
+	// Name is the name of the task in the Pipeline
+	Name string
+
+	// Task is the referenced Task
+	Task *Task
+
+	RunAfter []string
+
+	// Params are the values given to the params of the Task, in the order of the Task
+	Params []*ParamValue
+
+	// Workspaces bind the workspaces of the Task, in the order of the Task
+	Workspaces []*WorkspaceBinding
+
+	// Pos is the position of the pipelinetask marker
+	Pos token.Pos
+}
+
+// ParamValue is the value given to a param of a Task
+type ParamValue struct {
+	Name  string
+	Value string
+}
+
+// WorkspaceBinding binds a workspace of a Task to a workspace of the Pipeline
+type WorkspaceBinding struct {
+	Name      string
+	Workspace string
+}
+
+// Param returns the param with the given name or nil
+func (p *Pipeline) Param(name string) *Param {
+	for _, param := range p.Params {
+		if param.Name == name {
+			return param
+		}
+	}
+
+	return nil
+}
+
+// Workspace returns the workspace with the given name or nil
+func (p *Pipeline) Workspace(name string) *Workspace {
+	for _, workspace := range p.Workspaces {
+		if workspace.Name == name {
+			return workspace
+		}
+	}
+
+	return nil
+}
+
+// Task returns the task with the given name or nil
+func (p *Pipeline) Task(name string) *PipelineTask {
+	for _, task := range p.Tasks {
+		if task.Name == name {
+			return task
+		}
+	}
+
+	return nil
+}
+
+// BuildPipeline returns the Pipeline of the package or nil if the package is not a Pipeline.
+// The tasks of the Pipeline are looked up in roots, they are built with Build.
+// If the markers are not valid, ErrInvalidPipeline is returned and every diagnostic
+// is added, with its position, to the errors of the package.
+func (b *Builder) BuildPipeline(collector *markers.Collector, pkg *loader.Package, roots []*loader.Package) (*Pipeline, error) {
+	if built, ok := b.pipelines[pkg]; ok {
+		return built.pipeline, built.err
+	}
+
+	pipeline, errs := b.buildPipeline(collector, pkg, roots)
+
+	var err error
+	if len(errs) > 0 {
+		pkg.AddError(loader.ErrList(errs))
+		pipeline, err = nil, ErrInvalidPipeline
+	}
+
+	b.pipelines[pkg] = builtPipeline{pipeline: pipeline, err: err}
+	return pipeline, err
+}
+
+func (b *Builder) buildPipeline(collector *markers.Collector, pkg *loader.Package, roots []*loader.Package) (*Pipeline, []error) {
+	logger := b.Logger.With("pkg", pkg.Name)
+
+	markersByNode, err := collector.MarkersInPackage(pkg)
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	var pipelineMarker *ttmarkers.Pipeline
+	var pipelineFile *ast.File
+	var pipelineNode loader.Node
+	var errs []error
+
+	comments := newMarkerComments(collector.Registry)
+	for _, file := range pkg.Syntax {
+		for _, rawPipeline := range markersByNode[file][ttmarkers.MarkerPipeline] {
+			node := comments.find(file, ttmarkers.MarkerPipeline, rawPipeline)
+			if pipelineMarker != nil {
+				errs = append(errs, loader.ErrFromNode(
+					fmt.Errorf("pipeline marker is already declared in %s", fileName(pkg, pipelineFile)),
+					node))
+				continue
+			}
+
+			if marker, ok := rawPipeline.(ttmarkers.Pipeline); ok {
+				pipelineMarker, pipelineFile, pipelineNode = &marker, file, node
+			}
+		}
+	}
+
+	if pipelineMarker == nil {
+		return nil, errs
+	}
+
+	logger.Info("found pipeline", "pipeline", pipelineMarker.Name)
+
+	pipeline := &Pipeline{
+		Name:        pipelineMarker.Name,
+		Version:     pipelineMarker.Version,
+		Description: packageDoc(pkg),
+		Package:     pkg,
+	}
+
+	workspaces, workspaceErrs := buildWorkspaces(logger, pkg, markersByNode)
+	pipeline.Workspaces = workspaces
+	errs = append(errs, workspaceErrs...)
+
+	specs := typeSpecsOf(pkg)
+	err = markers.EachType(collector, pkg, func(info *markers.TypeInfo) {
+		rawParam := info.Markers.Get(ttmarkers.MarkerParam)
+		if param, ok := rawParam.(ttmarkers.Param); ok {
+			logger.Info("parameter found", "param", param.Name)
+
+			// ensure no duplication
+			if previous := pipeline.Param(param.Name); previous != nil {
+				errs = append(errs, loader.ErrFromNode(
+					fmt.Errorf("parameter %q is already declared by type %s", param.Name, previous.GoType),
+					info.RawSpec))
+				return
+			}
+
+			builtParam, paramErrs := buildParam(param, info, specs)
+			if len(paramErrs) > 0 {
+				errs = append(errs, paramErrs...)
+				return
+			}
+
+			pipeline.Params = append(pipeline.Params, builtParam)
+		}
+
+		if info.Markers.Get(ttmarkers.MarkerResult) != nil {
+			errs = append(errs, loader.ErrFromNode(errors.New("a pipeline can not declare results"), info.RawSpec))
+		}
+	})
+	if err != nil {
+		return nil, append(errs, err)
+	}
+
+	// the tasks are declared first so that they can reference each other in any order
+	var taskMarkers []ttmarkers.PipelineTask
+	for _, file := range pkg.Syntax {
+		for _, rawTask := range markersByNode[file][ttmarkers.MarkerPipelineTask] {
+			marker, ok := rawTask.(ttmarkers.PipelineTask)
+			if !ok {
+				continue
+			}
+
+			node := comments.find(file, ttmarkers.MarkerPipelineTask, rawTask)
+			if pipeline.Task(marker.Name) != nil {
+				errs = append(errs, loader.ErrFromNode(
+					fmt.Errorf("task %q is declared more than once in the pipeline", marker.Name),
+					node))
+				continue
+			}
+
+			task, err := b.lookupTask(collector, marker.Task, roots)
+			if err != nil {
+				errs = append(errs, loader.ErrFromNode(fmt.Errorf("task %q: %w", marker.Name, err), node))
+				continue
+			}
+
+			pipeline.Tasks = append(pipeline.Tasks, &PipelineTask{
+				Name:     marker.Name,
+				Task:     task,
+				RunAfter: marker.RunAfter,
+				Pos:      node.Pos(),
+			})
+			taskMarkers = append(taskMarkers, marker)
+		}
+	}
+
+	if len(pipeline.Tasks) == 0 && len(errs) == 0 {
+		errs = append(errs, loader.ErrFromNode(
+			fmt.Errorf("pipeline %q has no task, declare them with the pipelinetask marker", pipeline.Name),
+			pipelineNode))
+	}
+
+	for i, pipelineTask := range pipeline.Tasks {
+		for _, taskErr := range checkPipelineTask(pipeline, pipelineTask, taskMarkers[i]) {
+			errs = append(errs, loader.ErrFromNode(fmt.Errorf("task %q: %w", pipelineTask.Name, taskErr), posNode(pipelineTask.Pos)))
+		}
+	}
+
+	return pipeline, errs
+}
+
+// markerComments finds the comments holding the package-level markers returned by the collector,
+// which only tells the file they are in
+type markerComments struct {
+	registry *markers.Registry
+
+	// found are the comments already returned so identical markers get their own comment
+	found map[*ast.Comment]struct{}
+}
+
+func newMarkerComments(registry *markers.Registry) *markerComments {
+	return &markerComments{
+		registry: registry,
+		found:    make(map[*ast.Comment]struct{}),
+	}
+}
+
+// find returns the first comment of the file holding the marker with the given name and value,
+// the package clause of the file when there is none
+func (c *markerComments) find(file *ast.File, name string, value interface{}) loader.Node {
+	for _, group := range file.Comments {
+		if insideDecl(file, group) {
+			continue
+		}
+
+		for _, comment := range group.List {
+			if _, found := c.found[comment]; found {
+				continue
+			}
+
+			text := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
+			def := c.registry.Lookup(text, markers.DescribesPackage)
+			if def == nil || def.Name != name {
+				continue
+			}
+
+			parsed, err := def.Parse(text)
+			if err == nil && reflect.DeepEqual(parsed, value) {
+				c.found[comment] = struct{}{}
+				return comment
+			}
+		}
+	}
+
+	return file.Name
+}
+
+// lookupTask builds the Task of the root package with the given import path
+func (b *Builder) lookupTask(collector *markers.Collector, importPath string, roots []*loader.Package) (*Task, error) {
+	for _, root := range roots {
+		if root.PkgPath != importPath {
+			continue
+		}
+
+		task, err := b.Build(collector, root)
+		if errors.Is(err, ErrInvalidTask) {
+			return nil, fmt.Errorf("package %q is not a valid task, see its errors", importPath)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if task == nil {
+			return nil, fmt.Errorf("package %q has no task marker", importPath)
+		}
+
+		if task.StepAction {
+			return nil, fmt.Errorf("package %q is a stepaction, it can only be referenced by the steps of a task", importPath)
+		}
+
+		return task, nil
+	}
+
+	return nil, fmt.Errorf("package %q is not part of the generation, add it to the input packages", importPath)
+}
+
+// checkPipelineTask checks the references of the marker and fills the params and workspaces of the task
+func checkPipelineTask(pipeline *Pipeline, pipelineTask *PipelineTask, marker ttmarkers.PipelineTask) []error {
+	var errs []error
+	task := pipelineTask.Task
+
+	for _, runAfter := range pipelineTask.RunAfter {
+		if runAfter == pipelineTask.Name || pipeline.Task(runAfter) == nil {
+			errs = append(errs, fmt.Errorf("runAfter references unknown task %q", runAfter))
+		}
+	}
+
+	for _, name := range sortedKeys(marker.Params) {
+		if task.Param(name) == nil {
+			errs = append(errs, fmt.Errorf("task %s has no param %q", task.Name, name))
+		}
+	}
+
+	for _, param := range task.Params {
+		value, ok := marker.Params[param.Name]
+		if !ok {
+			if param.Default == nil {
+				errs = append(errs, fmt.Errorf("param %q of task %s is required", param.Name, task.Name))
+			}
+
+			continue
+		}
+
+		if err := checkParamValue(pipeline, pipelineTask, param, value); err != nil {
+			errs = append(errs, fmt.Errorf("param %q: %w", param.Name, err))
+			continue
+		}
+
+		pipelineTask.Params = append(pipelineTask.Params, &ParamValue{
+			Name:  param.Name,
+			Value: value,
+		})
+	}
+
+	for _, name := range sortedKeys(marker.Workspaces) {
+		if task.Workspace(name) == nil {
+			errs = append(errs, fmt.Errorf("task %s has no workspace %q", task.Name, name))
+		}
+	}
+
+	for _, workspace := range task.Workspaces {
+		bound, ok := marker.Workspaces[workspace.Name]
+		if !ok {
+			if !workspace.Optional {
+				errs = append(errs, fmt.Errorf("workspace %q of task %s is required", workspace.Name, task.Name))
+			}
+
+			continue
+		}
+
+		if pipeline.Workspace(bound) == nil {
+			errs = append(errs, fmt.Errorf("workspace %q is bound to unknown pipeline workspace %q", workspace.Name, bound))
+			continue
+		}
+
+		pipelineTask.Workspaces = append(pipelineTask.Workspaces, &WorkspaceBinding{
+			Name:      workspace.Name,
+			Workspace: bound,
+		})
+	}
+
+	return errs
+}
+
+// referenceRegexp matches the variables of a value referencing params or results
+var referenceRegexp = regexp.MustCompile(`\$\((params|tasks)\.([^)]*)\)`)
+
+// reference is a variable of a value referencing a param of the Pipeline or a result of one of its tasks
+type reference struct {
+	// Type is the type of the referenced value
+	Type TektonType
+
+	// Whole means the whole array or object is referenced with [*]
+	Whole bool
+}
+
+// checkParamValue checks the references of the value match the type of the param,
+// a value without reference must be allowed by the enum and the pattern of the param
+func checkParamValue(pipeline *Pipeline, pipelineTask *PipelineTask, param *Param, value string) error {
+	matches := referenceRegexp.FindAllStringSubmatchIndex(value, -1)
+	if len(matches) == 0 && param.Type == TypeString {
+		return param.CheckValue(value)
+	}
+
+	var refs []reference
+	for _, match := range matches {
+		ref, err := resolveReference(pipeline, pipelineTask, value[match[2]:match[3]], value[match[4]:match[5]])
+		if err != nil {
+			return err
+		}
+
+		refs = append(refs, ref)
+	}
+
+	if param.Type == TypeString {
+		for _, ref := range refs {
+			if ref.Whole || ref.Type != TypeString {
+				return fmt.Errorf("string param can not be given a whole %s in %q", ref.Type, value)
+			}
+		}
+
+		return nil
+	}
+
+	// arrays and objects can only be given as a whole
+	if len(matches) != 1 || matches[0][0] != 0 || matches[0][1] != len(value) ||
+		!refs[0].Whole || refs[0].Type != param.Type {
+		return fmt.Errorf("%s param must be given a whole %s, e.g. $(params.name[*])", param.Type, param.Type)
+	}
+
+	return nil
+}
+
+// resolveReference resolves a params.<name> or tasks.<task>.results.<name> reference,
+// both can be followed by [*] to reference a whole array or object or by .<key> to
+// reference a key of an object
+func resolveReference(pipeline *Pipeline, pipelineTask *PipelineTask, kind, path string) (reference, error) {
+	var name, key string
+	var typ TektonType
+	var properties []*Property
+	var whole bool
+
+	if strings.HasSuffix(path, "[*]") {
+		whole, path = true, strings.TrimSuffix(path, "[*]")
+	}
+
+	switch kind {
+	case "params":
+		name, key, _ = strings.Cut(path, ".")
+
+		param := pipeline.Param(name)
+		if param == nil {
+			return reference{}, fmt.Errorf("pipeline has no param %q", name)
+		}
+
+		typ, properties = param.Type, param.Properties
+	default:
+		parts := strings.SplitN(path, ".", 4)
+		if len(parts) < 3 || parts[1] != "results" {
+			return reference{}, fmt.Errorf("can not parse reference $(tasks.%s), expected $(tasks.<task>.results.<name>)", path)
+		}
+
+		name = parts[2]
+		if len(parts) == 4 {
+			key = parts[3]
+		}
+
+		source := pipeline.Task(parts[0])
+		if source == nil || source == pipelineTask {
+			return reference{}, fmt.Errorf("reference to unknown task %q", parts[0])
+		}
+
+		result := source.Task.Result(name)
+		if result == nil {
+			return reference{}, fmt.Errorf("task %s has no result %q", source.Task.Name, name)
+		}
+
+		typ, properties = result.Type, result.Properties
+	}
+
+	if key == "" {
+		if typ != TypeString && !whole {
+			return reference{}, fmt.Errorf("%s %q must be referenced with [*]", typ, name)
+		}
+
+		if typ == TypeString && whole {
+			return reference{}, fmt.Errorf("string %q can not be referenced with [*]", name)
+		}
+
+		return reference{Type: typ, Whole: whole}, nil
+	}
+
+	if typ != TypeObject || whole {
+		return reference{}, fmt.Errorf("only the keys of an object can be referenced, %q is not one", name+"."+key)
+	}
+
+	for _, property := range properties {
+		if property.Name == key {
+			return reference{Type: property.Type}, nil
+		}
+	}
+
+	return reference{}, fmt.Errorf("object %q has no key %q", name, key)
+}
+
+// sortedKeys returns the keys of the map in a stable order
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
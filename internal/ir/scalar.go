@@ -0,0 +1,73 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ir
+
+import (
+	"strconv"
+	"time"
+)
+
+// ScalarDuration is the kind of time.Duration, the only scalar which is not a builtin
+const ScalarDuration = "time.Duration"
+
+// scalarBitSizes are the bit sizes of the scalar kinds, 0 is the size of int
+var scalarBitSizes = map[string]int{
+	"bool":         0,
+	"int":          0,
+	"int8":         8,
+	"int16":        16,
+	"int32":        32,
+	"int64":        64,
+	"rune":         32,
+	"uint":         0,
+	"uint8":        8,
+	"uint16":       16,
+	"uint32":       32,
+	"uint64":       64,
+	"byte":         8,
+	"float32":      32,
+	"float64":      64,
+	ScalarDuration: 64,
+}
+
+// ScalarBitSize returns the bit size a scalar kind is parsed with,
+// ok is false if the kind is not a scalar
+func ScalarBitSize(kind string) (bitSize int, ok bool) {
+	bitSize, ok = scalarBitSizes[kind]
+	return bitSize, ok
+}
+
+// ParseScalar checks the value is a valid string form of the scalar kind
+func ParseScalar(kind, value string) error {
+	bitSize := scalarBitSizes[kind]
+
+	var err error
+	switch kind {
+	case "bool":
+		_, err = strconv.ParseBool(value)
+	case "int", "int8", "int16", "int32", "int64", "rune":
+		_, err = strconv.ParseInt(value, 10, bitSize)
+	case "uint", "uint8", "uint16", "uint32", "uint64", "byte":
+		_, err = strconv.ParseUint(value, 10, bitSize)
+	case "float32", "float64":
+		_, err = strconv.ParseFloat(value, bitSize)
+	case ScalarDuration:
+		_, err = time.ParseDuration(value)
+	}
+
+	return err
+}
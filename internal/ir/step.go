@@ -0,0 +1,270 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ir
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"strings"
+
+	ttmarkers "github.com/raskyld/go-tektasker/pkg/markers"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+// StepEnvVar is the environment variable holding the name of the step
+// to dispatch when the binary serves several entrypoints
+const StepEnvVar = "TEKTASKER_STEP"
+
+// stepMarker is a step marker along with where it was found
+type stepMarker struct {
+	ttmarkers.Step
+
+	// fn is the function the marker is put on, if any
+	fn *ast.FuncDecl
+
+	pos token.Pos
+}
+
+// collectStepMarkers finds the step markers of the package in declaration order.
+// NB(raskyld): controller-tools does not associate markers with functions, the ones in
+// the GoDoc of a function are dropped and the ones right above it are considered
+// package-level, so we need to walk the comments ourselves to know which function
+// a marker is attached to.
+// The comments inside a declaration are never markers of the package so they are skipped.
+func collectStepMarkers(registry *markers.Registry, pkg *loader.Package) ([]stepMarker, []error) {
+	var steps []stepMarker
+	var errs []error
+
+	for _, file := range pkg.Syntax {
+		attachedTo := funcsByComment(file)
+
+		for _, group := range file.Comments {
+			if insideDecl(file, group) {
+				continue
+			}
+
+			for _, comment := range group.List {
+				text := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
+				if !strings.HasPrefix(text, "+") {
+					continue
+				}
+
+				def := registry.Lookup(text, markers.DescribesPackage)
+				if def == nil || def.Name != ttmarkers.MarkerStep {
+					continue
+				}
+
+				raw, err := def.Parse(text)
+				if err != nil {
+					// the collector only reports the ones it considers package-level,
+					// it looks up the ones in the GoDoc of a function as type-level markers
+					if fn := attachedTo[group]; fn != nil && fn.Doc == group {
+						errs = append(errs, loader.ErrFromNode(err, comment))
+					}
+					continue
+				}
+
+				step, ok := raw.(ttmarkers.Step)
+				if !ok {
+					continue
+				}
+
+				marker := stepMarker{Step: step, fn: attachedTo[group], pos: comment.Pos()}
+				if marker.fn != nil {
+					if step.Entrypoint != "" && step.Entrypoint != marker.fn.Name.Name {
+						errs = append(errs, loader.ErrFromNode(
+							fmt.Errorf("step %q is put on function %s but declares entrypoint %s",
+								step.Name, marker.fn.Name.Name, step.Entrypoint),
+							comment))
+						continue
+					}
+
+					marker.Entrypoint = marker.fn.Name.Name
+				}
+
+				steps = append(steps, marker)
+			}
+		}
+	}
+
+	return steps, errs
+}
+
+// funcsByComment maps the comment groups attached to a top-level function to this function,
+// that is its GoDoc and the comment group right above it.
+// The comments above main and init are left to the package as they can not be entrypoints
+// and are a common place for package-level markers.
+func funcsByComment(file *ast.File) map[*ast.CommentGroup]*ast.FuncDecl {
+	attachedTo := make(map[*ast.CommentGroup]*ast.FuncDecl)
+
+	previousEnd := file.Name.End()
+	for _, decl := range file.Decls {
+		fn, isFunc := decl.(*ast.FuncDecl)
+		if !isFunc || fn.Name.Name == "main" || fn.Name.Name == "init" {
+			previousEnd = decl.End()
+			continue
+		}
+
+		start := fn.Pos()
+		if fn.Doc != nil {
+			attachedTo[fn.Doc] = fn
+			start = fn.Doc.Pos()
+		}
+
+		var above *ast.CommentGroup
+		for _, group := range file.Comments {
+			if group.Pos() > previousEnd && group.End() < start {
+				above = group
+			}
+		}
+
+		if above != nil {
+			attachedTo[above] = fn
+		}
+
+		previousEnd = fn.End()
+	}
+
+	return attachedTo
+}
+
+// insideDecl tells whether the comment group is within the body of a top-level declaration
+func insideDecl(file *ast.File, group *ast.CommentGroup) bool {
+	for _, decl := range file.Decls {
+		if group.Pos() > decl.Pos() && group.End() <= decl.End() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// buildSteps computes the steps of the Task from its step markers
+func buildSteps(task *Task, registry *markers.Registry) []error {
+	stepMarkers, errs := collectStepMarkers(registry, task.Package)
+	if len(stepMarkers) == 0 && len(errs) == 0 {
+		// Without step markers, a Task is made of a single step running the main package
+		task.Steps = []*Step{
+			{
+				Params:  task.Params,
+				Results: task.Results,
+				Pos:     task.Pos,
+			},
+		}
+
+		return nil
+	}
+
+	for _, marker := range stepMarkers {
+		if task.Step(marker.Name) != nil {
+			errs = append(errs, loader.ErrFromNode(
+				fmt.Errorf("step %q is declared more than once", marker.Name), marker))
+			continue
+		}
+
+		step := &Step{
+			Name:       marker.Name,
+			Entrypoint: marker.Entrypoint,
+			Image:      marker.Image,
+			Command:    marker.Command,
+			Params:     task.Params,
+			Results:    task.Results,
+			Pos:        marker.pos,
+		}
+
+		if marker.Entrypoint != "" {
+			if err := checkEntrypoint(task.Package, marker); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+
+		if marker.Params != nil {
+			step.Params = make([]*Param, 0, len(*marker.Params))
+			for _, name := range *marker.Params {
+				param := task.Param(name)
+				if param == nil {
+					errs = append(errs, loader.ErrFromNode(
+						fmt.Errorf("step %q uses unknown parameter %q", marker.Name, name), marker))
+					continue
+				}
+
+				step.Params = append(step.Params, param)
+			}
+		}
+
+		if marker.Results != nil {
+			step.Results = make([]*Result, 0, len(*marker.Results))
+			for _, name := range *marker.Results {
+				result := task.Result(name)
+				if result == nil {
+					errs = append(errs, loader.ErrFromNode(
+						fmt.Errorf("step %q uses unknown result %q", marker.Name, name), marker))
+					continue
+				}
+
+				step.Results = append(step.Results, result)
+			}
+		}
+
+		task.Steps = append(task.Steps, step)
+	}
+
+	return errs
+}
+
+// checkEntrypoint ensures the entrypoint of a step is a `func() error` of the package
+func checkEntrypoint(pkg *loader.Package, marker stepMarker) error {
+	fn := marker.fn
+	if fn == nil {
+		for _, file := range pkg.Syntax {
+			for _, decl := range file.Decls {
+				if decl, isFunc := decl.(*ast.FuncDecl); isFunc && decl.Recv == nil && decl.Name.Name == marker.Entrypoint {
+					fn = decl
+				}
+			}
+		}
+	}
+
+	if fn == nil {
+		return loader.ErrFromNode(
+			fmt.Errorf("entrypoint %s of step %q is not a function of the package", marker.Entrypoint, marker.Name),
+			marker)
+	}
+
+	results := fn.Type.Results
+	isError := results != nil && len(results.List) == 1 && len(results.List[0].Names) <= 1
+	if isError {
+		ident, isIdent := results.List[0].Type.(*ast.Ident)
+		isError = isIdent && ident.Name == "error"
+	}
+
+	if fn.Recv != nil || fn.Type.TypeParams != nil || len(fn.Type.Params.List) > 0 || !isError {
+		return loader.ErrFromNode(
+			fmt.Errorf("entrypoint %s of step %q must have the func() error signature", fn.Name.Name, marker.Name),
+			fn.Name)
+	}
+
+	return nil
+}
+
+// Pos makes a stepMarker a loader.Node
+func (m stepMarker) Pos() token.Pos {
+	return m.pos
+}
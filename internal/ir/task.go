@@ -0,0 +1,364 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package ir contains the intermediate representation of a Task.
+//
+// The representation is built once per Go package from the markers collected
+// by controller-tools, then consumed by every generator (YAML manifests,
+// Go helpers...) so that they all agree by construction.
+package ir
+
+import (
+	"go/token"
+
+	"sigs.k8s.io/controller-tools/pkg/loader"
+)
+
+// TektonType is the type of a param or a result as understood by Tekton
+type TektonType string
+
+const (
+	TypeString TektonType = "string"
+	TypeArray  TektonType = "array"
+	TypeObject TektonType = "object"
+)
+
+// Encoding is the way a Go value is represented in its Tekton string form
+type Encoding string
+
+const (
+	// EncodingRaw means the string is used as is, only types made of string can use it
+	EncodingRaw Encoding = "raw"
+
+	// EncodingJSON means the string is expected to be valid JSON
+	EncodingJSON Encoding = "json"
+
+	// EncodingScalar means the string is parsed and formatted with strconv,
+	// or time.ParseDuration, according to the builtin scalar kind of the type
+	EncodingScalar Encoding = "scalar"
+)
+
+// Task is the intermediate representation of a Tekton Task built from
+// the markers of a single Go package
+type Task struct {
+	// Name is the name of the Task manifest
+	Name string
+
+	// Version of the Task as communicated to the users
+	Version string
+
+	// Description is built from the package-level GoDoc
+	Description string
+
+	// StepAction means the package is a StepAction, i.e. a single step referenced
+	// by other Tasks, rather than a Task
+	StepAction bool
+
+	// DisplayName is the human-readable name of the Task in the catalog
+	DisplayName string
+
+	// MinPipelinesVersion is the oldest Tekton Pipelines release the Task works with
+	MinPipelinesVersion string
+
+	// Categories, Tags and Platforms are used by the catalog to index the Task
+	Categories []string
+	Tags       []string
+	Platforms  []string
+
+	// Package is the Go package the Task is built from
+	Package *loader.Package
+
+	// Pos is the position of the package clause holding the task marker
+	Pos token.Pos
+
+	// Image is how the image of the steps is built, nil to use the strategy of the configuration
+	Image *Image
+
+	Params     []*Param
+	Results    []*Result
+	Workspaces []*Workspace
+	Steps      []*Step
+	Sidecars   []*Sidecar
+}
+
+// Param is a Go type marked as a Task parameter
+type Param struct {
+	// Name is the name of the param in the Task manifest
+	Name string
+
+	// GoType is the name of the marked Go type
+	GoType string
+
+	// Type is the Tekton type of the param
+	Type TektonType
+
+	// Encoding is how the Go value is decoded from the param value
+	Encoding Encoding
+
+	// ItemEncoding is how each element of an array param is decoded
+	ItemEncoding Encoding
+
+	// Scalar and ItemScalar are the builtin scalar kinds (e.g. int64 or time.Duration)
+	// of the param and of its elements, set for EncodingScalar only
+	Scalar     string
+	ItemScalar string
+
+	// EnvVar is the environment variable holding the value of the param,
+	// it is empty for array params as they are passed through the args of the step
+	// and for object params as each of their properties has its own
+	EnvVar string
+
+	// Description is the GoDoc of the marked type
+	Description string
+
+	// Default is the default value of the param, it is either a string,
+	// a []interface{} for arrays or a map[string]interface{} for objects.
+	// A nil Default means the param is required.
+	Default interface{}
+
+	// Properties are the keys of an object param, sorted by name
+	Properties []*Property
+
+	// Enum is the set of the allowed values of a string param, empty to allow any
+	Enum []string
+
+	// Pattern is the regular expression the values of a string param must match, empty to allow any
+	Pattern string
+
+	// Pos is the position of the marked type
+	Pos token.Pos
+}
+
+// Property is a key of an object param
+type Property struct {
+	// Name is the key, taken from the JSON tag of the field
+	Name string
+
+	// Field is the name of the Go struct field
+	Field string
+
+	// Type is the Tekton type of the property
+	Type TektonType
+
+	// Required is false when the field is tagged with omitempty, an optional property
+	// defaults to an empty string. The properties of a result are always written
+	Required bool
+
+	// EnvVar is the environment variable holding the value of the property,
+	// it is empty for the properties of a result
+	EnvVar string
+
+	// Pos is the position of the field
+	Pos token.Pos
+}
+
+// Result is a Go type marked as a Task result
+type Result struct {
+	// Name is the name of the result in the Task manifest
+	Name string
+
+	// GoType is the name of the marked Go type
+	GoType string
+
+	// Type is the Tekton type of the result
+	Type TektonType
+
+	// Encoding is how the Go value is encoded to the result file
+	Encoding Encoding
+
+	// Scalar is the builtin scalar kind of the result, set for EncodingScalar only
+	Scalar string
+
+	// EnvVar is the environment variable holding the path of the result file
+	EnvVar string
+
+	// Description is the GoDoc of the marked type
+	Description string
+
+	// Properties are the keys of an object result, sorted by name
+	Properties []*Property
+
+	// Pos is the position of the marked type
+	Pos token.Pos
+}
+
+// Workspace is a workspace requested by the Task
+type Workspace struct {
+	Name        string
+	Description string
+	MountPath   string
+	ReadOnly    bool
+	Optional    bool
+
+	// EnvVar is the environment variable holding the path of the workspace
+	EnvVar string
+
+	// Pos is the position of the package clause holding the workspace marker
+	Pos token.Pos
+}
+
+// Sidecar is a container running next to the steps of the Task
+type Sidecar struct {
+	Name    string
+	Image   string
+	Command []string
+
+	// ReadinessPort is the TCP port probed to know if the sidecar is ready, 0 to disable
+	ReadinessPort int
+}
+
+// Image strategies
+const (
+	ImageStrategyKo       = "ko"
+	ImageStrategyFixed    = "fixed"
+	ImageStrategyTemplate = "template"
+)
+
+// Image is how the container image running the Go package of a Task is built
+type Image struct {
+	// Strategy is one of ImageStrategyKo, ImageStrategyFixed or ImageStrategyTemplate
+	Strategy string
+
+	// Reference is the image reference of the fixed strategy
+	// or the template of the image reference of the template strategy
+	Reference string
+
+	// Command is the entrypoint of the image, nil for the default of the strategy
+	Command []string
+}
+
+// Resources are the compute resources of a step, indexed by resource name (i.e. cpu or memory)
+type Resources struct {
+	Requests map[string]string
+	Limits   map[string]string
+}
+
+// SecurityContext is the security context of a step
+type SecurityContext struct {
+	RunAsNonRoot           *bool
+	RunAsUser              *int64
+	ReadOnlyRootFilesystem *bool
+	AddCapabilities        []string
+	DropCapabilities       []string
+}
+
+// Step is a step of the Task running a Go entrypoint
+type Step struct {
+	// Name of the step, may be empty for the default step
+	Name string
+
+	// Entrypoint is the Go function run by the step, empty for the main function
+	Entrypoint string
+
+	// Image overrides the default image of the step
+	Image string
+
+	// Command overrides the default command of the step
+	Command []string
+
+	// Params are the params exposed to the step
+	Params []*Param
+
+	// Results are the results the step can write
+	Results []*Result
+
+	// Resources are the compute resources of the step, may be nil
+	Resources *Resources
+
+	// SecurityContext of the step, may be nil
+	SecurityContext *SecurityContext
+
+	// Pos is the position of the step marker
+	Pos token.Pos
+}
+
+// Param returns the param with the given name or nil
+func (t *Task) Param(name string) *Param {
+	for _, param := range t.Params {
+		if param.Name == name {
+			return param
+		}
+	}
+
+	return nil
+}
+
+// Result returns the result with the given name or nil
+func (t *Task) Result(name string) *Result {
+	for _, result := range t.Results {
+		if result.Name == name {
+			return result
+		}
+	}
+
+	return nil
+}
+
+// Workspace returns the workspace with the given name or nil
+func (t *Task) Workspace(name string) *Workspace {
+	for _, workspace := range t.Workspaces {
+		if workspace.Name == name {
+			return workspace
+		}
+	}
+
+	return nil
+}
+
+// Step returns the step with the given name or nil
+func (t *Task) Step(name string) *Step {
+	for _, step := range t.Steps {
+		if step.Name == name {
+			return step
+		}
+	}
+
+	return nil
+}
+
+// Dispatched tells whether the binary of the Task serves several entrypoints,
+// in which case the step to run is selected with StepEnvVar
+func (t *Task) Dispatched() bool {
+	for _, step := range t.Steps {
+		if step.Entrypoint != "" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sidecar returns the sidecar with the given name or nil
+func (t *Task) Sidecar(name string) *Sidecar {
+	for _, sidecar := range t.Sidecars {
+		if sidecar.Name == name {
+			return sidecar
+		}
+	}
+
+	return nil
+}
+
+// HasRequiredProperty tells whether one of the properties of the object param is required
+func (p *Param) HasRequiredProperty() bool {
+	for _, property := range p.Properties {
+		if property.Required {
+			return true
+		}
+	}
+
+	return false
+}
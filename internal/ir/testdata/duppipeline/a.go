@@ -0,0 +1,6 @@
+// +tektasker:pipeline:name=dup-a,version=0.1
+// +tektasker:pipelinetask:name=greet,task=github.com/raskyld/go-tektasker/internal/ir/testdata/duppipeline/greet,params={who: "world"}
+
+package main
+
+func main() {}
@@ -0,0 +1,3 @@
+package main
+
+// +tektasker:pipeline:name=dup-b,version=0.1
@@ -0,0 +1,5 @@
+// +tektasker:task:name=dup-a,version=0.1
+
+package main
+
+func main() {}
@@ -0,0 +1,3 @@
+// +tektasker:task:name=dup-b,version=0.1
+
+package main
@@ -0,0 +1,23 @@
+// +tektasker:task:name=defaults,version=0.1
+
+package main
+
+func main() {}
+
+// +tektasker:param:name=optional,strict=true
+type Optional struct {
+	URL      string `json:"url,omitempty"`
+	Revision string `json:"revision,omitempty"`
+}
+
+// +tektasker:param:name=mixed,strict=true
+type Mixed struct {
+	URL      string `json:"url"`
+	Revision string `json:"revision,omitempty"`
+}
+
+// +tektasker:param:name=completed,strict=true,default=`{"url":"https://example.com"}`
+type Completed struct {
+	URL      string `json:"url"`
+	Revision string `json:"revision,omitempty"`
+}
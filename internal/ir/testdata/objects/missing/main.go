@@ -0,0 +1,11 @@
+// +tektasker:task:name=missing,version=0.1
+
+package main
+
+func main() {}
+
+// +tektasker:param:name=repo,strict=true,default=`{"revision":"main"}`
+type Repo struct {
+	URL      string `json:"url"`
+	Revision string `json:"revision,omitempty"`
+}
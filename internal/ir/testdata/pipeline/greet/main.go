@@ -0,0 +1,15 @@
+// +tektasker:task:name=greet,version=0.1
+
+package main
+
+// +tektasker:param:name=who
+
+// Who to greet
+type Who string
+
+// +tektasker:result:name=greeting
+
+// Greeting is the result
+type Greeting string
+
+func main() {}
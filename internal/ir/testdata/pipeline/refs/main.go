@@ -0,0 +1,16 @@
+// +tektasker:pipeline:name=refs,version=0.1
+
+package main
+
+// +tektasker:param:name=name
+
+// Name of the greeted
+type Name string
+
+// +tektasker:pipelinetask:name=first,task=github.com/raskyld/go-tektasker/internal/ir/testdata/pipeline/greet,params={who: "$(params.name)"}
+// +tektasker:pipelinetask:name=unknown-task,task=github.com/raskyld/go-tektasker/internal/ir/testdata/pipeline/missing
+// +tektasker:pipelinetask:name=unknown-result,task=github.com/raskyld/go-tektasker/internal/ir/testdata/pipeline/greet,params={who: "$(tasks.first.results.nope)"}
+// +tektasker:pipelinetask:name=unknown-param,task=github.com/raskyld/go-tektasker/internal/ir/testdata/pipeline/greet,params={who: "$(params.nope)"}
+// +tektasker:pipelinetask:name=unknown-refs,task=github.com/raskyld/go-tektasker/internal/ir/testdata/pipeline/greet,runAfter=ghost,params={who: "$(tasks.ghost.results.greeting)", extra: "x"}
+
+func main() {}
@@ -0,0 +1,14 @@
+// +tektasker:pipeline:name=valid,version=0.1
+
+// Package main greets twice.
+package main
+
+// +tektasker:param:name=name
+
+// Name of the greeted
+type Name string
+
+// +tektasker:pipelinetask:name=first,task=github.com/raskyld/go-tektasker/internal/ir/testdata/pipeline/greet,params={who: "$(params.name)"}
+// +tektasker:pipelinetask:name=second,task=github.com/raskyld/go-tektasker/internal/ir/testdata/pipeline/greet,runAfter=first,params={who: "$(tasks.first.results.greeting)"}
+
+func main() {}
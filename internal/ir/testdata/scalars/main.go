@@ -0,0 +1,34 @@
+// +tektasker:task:name=scalars,version=0.1
+
+package main
+
+import (
+	"time"
+)
+
+func main() {}
+
+// +tektasker:param:name=mode
+type Mode byte
+
+// +tektasker:param:name=separator
+type Separator rune
+
+type Count int32
+
+// +tektasker:param:name=retries
+type Retries Count
+
+type Delay time.Duration
+
+// +tektasker:param:name=timeout
+type Timeout Delay
+
+// +tektasker:param:name=deadline
+type Deadline time.Time
+
+// +tektasker:param:name=counts
+type Counts []Count
+
+// +tektasker:result:name=attempts
+type Attempts Retries
@@ -0,0 +1,7 @@
+// +tektasker:task:name=duplicate,version=0.1
+// +tektasker:sidecar:name=redis,image="redis:7"
+// +tektasker:sidecar:name=redis,image="redis:6"
+
+package main
+
+func main() {}
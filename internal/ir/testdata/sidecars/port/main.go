@@ -0,0 +1,6 @@
+// +tektasker:task:name=port,version=0.1
+// +tektasker:sidecar:name=redis,image="redis:7",readinessPort=70000
+
+package main
+
+func main() {}
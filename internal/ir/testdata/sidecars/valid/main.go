@@ -0,0 +1,7 @@
+// +tektasker:task:name=valid,version=0.1
+// +tektasker:sidecar:name=redis,image="redis:7",command=redis-server;--save,readinessPort=6379
+// +tektasker:sidecar:name=registry,image="registry:2"
+
+package main
+
+func main() {}
@@ -0,0 +1,10 @@
+// +tektasker:task:name=malformed,version=0.1
+
+package main
+
+func main() {}
+
+// +tektasker:step:name=build,unknown=true
+func build() error {
+	return nil
+}
@@ -0,0 +1,6 @@
+// +tektasker:task:name=missing,version=0.1
+// +tektasker:step:name=build,entrypoint=build
+
+package main
+
+func main() {}
@@ -0,0 +1,18 @@
+// +tektasker:task:name=several,version=0.1
+// +tektasker:step:name=prepare,image=alpine,command=true
+
+package main
+
+func main() {}
+
+// +tektasker:step:name=build
+func build() error {
+	// +tektasker:step:name=ignored
+	return nil
+}
+
+// Test runs the tests
+// +tektasker:step:name=test
+func test() error {
+	return nil
+}
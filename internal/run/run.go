@@ -0,0 +1,325 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package run runs a Task on the local machine by giving its steps
+// the same environment Tekton would.
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/raskyld/go-tektasker/internal/ir"
+	ttmarkers "github.com/raskyld/go-tektasker/pkg/markers"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+	"sigs.k8s.io/controller-tools/pkg/markers"
+)
+
+// Runner builds the binary of a Task and runs its steps one after the other
+type Runner struct {
+	Logger  *slog.Logger
+	Builder *ir.Builder
+
+	// Stdout and Stderr are given to the steps and to the build of the binary
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+func New(logger *slog.Logger, builder *ir.Builder) *Runner {
+	return &Runner{
+		Logger:  logger.With("component", "run"),
+		Builder: builder,
+		Stdout:  os.Stdout,
+		Stderr:  os.Stderr,
+	}
+}
+
+// Options are the inputs of a run
+type Options struct {
+	// Params overrides the defaults of the params, indexed by name.
+	// Arrays and objects are given as JSON.
+	Params map[string]string
+
+	// Workspaces binds workspaces to existing directories, indexed by name.
+	// The other workspaces are given a temporary directory.
+	Workspaces map[string]string
+}
+
+// Load the Task of the given package
+func (r *Runner) Load(input string) (*ir.Task, error) {
+	roots, err := loader.LoadRoots(input)
+	if err != nil {
+		return nil, err
+	}
+
+	registry := &markers.Registry{}
+	err = ttmarkers.Register(registry)
+	if err != nil {
+		return nil, err
+	}
+
+	collector := &markers.Collector{Registry: registry}
+
+	var tasks []*ir.Task
+	for _, root := range roots {
+		task, err := r.Builder.Build(collector, root)
+		if errors.Is(err, ir.ErrInvalidTask) {
+			// diagnostics are reported on the package
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if task != nil {
+			tasks = append(tasks, task)
+		}
+	}
+
+	if loader.PrintErrors(roots) {
+		return nil, fmt.Errorf("%w, see the errors above", ir.ErrInvalidTask)
+	}
+
+	if len(tasks) != 1 {
+		return nil, fmt.Errorf("%s must hold exactly one task, found %d", input, len(tasks))
+	}
+
+	return tasks[0], nil
+}
+
+// Run the steps of the Task in order and returns the value of the results they wrote,
+// indexed by name
+func (r *Runner) Run(ctx context.Context, task *ir.Task, opts Options) (map[string]string, error) {
+	params, err := resolveParams(task, opts.Params)
+	if err != nil {
+		return nil, err
+	}
+
+	for name := range opts.Workspaces {
+		if task.Workspace(name) == nil {
+			return nil, fmt.Errorf("task %s has no workspace %q", task.Name, name)
+		}
+	}
+
+	dir, err := os.MkdirTemp("", "tektasker-"+task.Name+"-")
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.RemoveAll(dir)
+
+	binary := filepath.Join(dir, task.Name)
+	r.Logger.Info("building task", "task", task.Name, "binary", binary)
+
+	build := exec.CommandContext(ctx, "go", "build", "-o", binary, ".")
+	build.Dir = packageDir(task.Package)
+	build.Stdout, build.Stderr = r.Stderr, r.Stderr
+	if err := build.Run(); err != nil {
+		return nil, fmt.Errorf("could not build task %s: %w", task.Name, err)
+	}
+
+	resultsDir := filepath.Join(dir, "results")
+	if err := os.Mkdir(resultsDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	workspaces := make(map[string]string, len(task.Workspaces))
+	for _, workspace := range task.Workspaces {
+		path, bound := opts.Workspaces[workspace.Name]
+		if !bound {
+			path = filepath.Join(dir, "workspaces", workspace.Name)
+			if err := os.MkdirAll(path, 0o755); err != nil {
+				return nil, err
+			}
+		}
+
+		workspaces[workspace.Name] = path
+	}
+
+	for _, step := range task.Steps {
+		if step.Image != "" || step.Command != nil {
+			r.Logger.Warn("skipping step which does not run the task binary", "step", step.Name)
+			continue
+		}
+
+		args, env := stepEnv(task, step, params, resultsDir, workspaces)
+
+		r.Logger.Info("running step", "step", step.Name)
+		r.Logger.Debug("step environment", "step", step.Name, "args", args, "env", env)
+
+		cmd := exec.CommandContext(ctx, binary, args...)
+		cmd.Env = append(os.Environ(), env...)
+		cmd.Stdout, cmd.Stderr = r.Stdout, r.Stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("step %q of task %s failed: %w", step.Name, task.Name, err)
+		}
+	}
+
+	results := make(map[string]string, len(task.Results))
+	for _, result := range task.Results {
+		value, err := os.ReadFile(filepath.Join(resultsDir, result.Name))
+		if errors.Is(err, fs.ErrNotExist) {
+			r.Logger.Warn("result was not written", "result", result.Name)
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		results[result.Name] = string(value)
+	}
+
+	return results, nil
+}
+
+// stepEnv computes the args and the environment of a step the way the steps of
+// the Task manifest are built, but with the actual values instead of Tekton variables
+func stepEnv(task *ir.Task, step *ir.Step, params map[string]interface{}, resultsDir string, workspaces map[string]string) ([]string, []string) {
+	args, stepEnv := ir.StepArgs(task, step, localValues{
+		params:     params,
+		resultsDir: resultsDir,
+		workspaces: workspaces,
+	})
+
+	env := make([]string, 0, len(stepEnv))
+	for _, variable := range stepEnv {
+		env = append(env, variable.Name+"="+variable.Value)
+	}
+
+	return args, env
+}
+
+// localValues gives the values of a local run to the steps
+type localValues struct {
+	// params are the resolved values of the params, indexed by name
+	params map[string]interface{}
+
+	// resultsDir is the directory the results are written to
+	resultsDir string
+
+	// workspaces are the directories bound to the workspaces, indexed by name
+	workspaces map[string]string
+}
+
+func (v localValues) Param(param *ir.Param) string {
+	return stringify(v.params[param.Name])
+}
+
+func (v localValues) Array(param *ir.Param) []string {
+	array, _ := v.params[param.Name].([]interface{})
+
+	values := make([]string, 0, len(array))
+	for _, item := range array {
+		values = append(values, stringify(item))
+	}
+
+	return values
+}
+
+func (v localValues) Property(param *ir.Param, property *ir.Property) (string, bool) {
+	object, _ := v.params[param.Name].(map[string]interface{})
+
+	value, ok := object[property.Name]
+	if !ok {
+		return "", false
+	}
+
+	return stringify(value), true
+}
+
+func (v localValues) Result(result *ir.Result) string {
+	return filepath.Join(v.resultsDir, result.Name)
+}
+
+func (v localValues) Workspace(workspace *ir.Workspace) string {
+	return v.workspaces[workspace.Name]
+}
+
+// resolveParams computes the value of every param from the overrides or the defaults
+func resolveParams(task *ir.Task, overrides map[string]string) (map[string]interface{}, error) {
+	for name := range overrides {
+		if task.Param(name) == nil {
+			return nil, fmt.Errorf("task %s has no parameter %q", task.Name, name)
+		}
+	}
+
+	params := make(map[string]interface{}, len(task.Params))
+	for _, param := range task.Params {
+		override, overridden := overrides[param.Name]
+		if !overridden {
+			if param.Default == nil {
+				return nil, fmt.Errorf("parameter %q has no default, set it with -p %s=<value>", param.Name, param.Name)
+			}
+
+			params[param.Name] = param.Default
+			continue
+		}
+
+		switch param.Type {
+		case ir.TypeArray:
+			var array []interface{}
+			if err := json.Unmarshal([]byte(override), &array); err != nil {
+				return nil, fmt.Errorf("value of array parameter %q must be a JSON array: %w", param.Name, err)
+			}
+
+			params[param.Name] = array
+		case ir.TypeObject:
+			var object map[string]interface{}
+			if err := json.Unmarshal([]byte(override), &object); err != nil {
+				return nil, fmt.Errorf("value of object parameter %q must be a JSON object: %w", param.Name, err)
+			}
+
+			params[param.Name] = object
+		default:
+			params[param.Name] = override
+		}
+	}
+
+	return params, nil
+}
+
+// stringify returns strings as is and encodes anything else in JSON
+func stringify(value interface{}) string {
+	if str, isString := value.(string); isString {
+		return str
+	}
+
+	buf, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+
+	return string(buf)
+}
+
+// packageDir is the directory holding the sources of the package
+func packageDir(pkg *loader.Package) string {
+	if len(pkg.GoFiles) == 0 {
+		return "."
+	}
+
+	return filepath.Dir(pkg.GoFiles[0])
+}
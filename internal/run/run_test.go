@@ -0,0 +1,185 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package run
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/raskyld/go-tektasker/internal/ir"
+)
+
+// testTask returns a Task with a string, an array and an object param
+// and a required string param, count
+func testTask() *ir.Task {
+	return &ir.Task{
+		Name: "hello",
+		Params: []*ir.Param{
+			{Name: "url", Type: ir.TypeString, EnvVar: ir.ParamEnvVar("url"), Default: "https://example.com"},
+			{Name: "files", Type: ir.TypeArray, Default: []interface{}{"a.txt", "b.txt"}},
+			{Name: "repo", Type: ir.TypeObject, Default: map[string]interface{}{"revision": "main"}, Properties: []*ir.Property{
+				{Name: "revision", EnvVar: ir.ParamEnvVar("repo.revision")},
+				{Name: "depth", EnvVar: ir.ParamEnvVar("repo.depth")},
+			}},
+			{Name: "count", Type: ir.TypeString, EnvVar: ir.ParamEnvVar("count")},
+		},
+		Results:    []*ir.Result{{Name: "digest", EnvVar: ir.ResultEnvVar("digest")}},
+		Workspaces: []*ir.Workspace{{Name: "source", EnvVar: ir.WorkspaceEnvVar("source")}},
+	}
+}
+
+func TestResolveParams(t *testing.T) {
+	tests := []struct {
+		name      string
+		overrides map[string]string
+		wantErr   bool
+		result    map[string]interface{}
+	}{
+		{
+			"Defaults",
+			map[string]string{"count": "3"},
+			false,
+			map[string]interface{}{
+				"url":   "https://example.com",
+				"files": []interface{}{"a.txt", "b.txt"},
+				"repo":  map[string]interface{}{"revision": "main"},
+				"count": "3",
+			},
+		},
+		{
+			"Overrides",
+			map[string]string{
+				"url":   "https://example.org",
+				"files": `["c.txt"]`,
+				"repo":  `{"revision":"v1","depth":1}`,
+				"count": "3",
+			},
+			false,
+			map[string]interface{}{
+				"url":   "https://example.org",
+				"files": []interface{}{"c.txt"},
+				"repo":  map[string]interface{}{"revision": "v1", "depth": float64(1)},
+				"count": "3",
+			},
+		},
+		{
+			"Missing required param",
+			nil,
+			true,
+			nil,
+		},
+		{
+			"Unknown param",
+			map[string]string{"count": "3", "unknown": "value"},
+			true,
+			nil,
+		},
+		{
+			"Array which is not a JSON array",
+			map[string]string{"count": "3", "files": "a.txt"},
+			true,
+			nil,
+		},
+		{
+			"Object which is not a JSON object",
+			map[string]string{"count": "3", "repo": `["main"]`},
+			true,
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := resolveParams(testTask(), test.overrides)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("resolveParams() error = %v, wantErr %v", err, test.wantErr)
+			}
+
+			if !reflect.DeepEqual(result, test.result) {
+				t.Errorf("unwanted diff, got\n---\n%v\n---\nwanted\n---\n%v", result, test.result)
+			}
+		})
+	}
+}
+
+func TestStepEnv(t *testing.T) {
+	task := testTask()
+	workspaces := map[string]string{"source": "/tmp/source"}
+
+	tests := []struct {
+		name   string
+		step   *ir.Step
+		params map[string]interface{}
+		args   []string
+		env    []string
+	}{
+		{
+			"Scalar, array and object params",
+			&ir.Step{Params: task.Params},
+			map[string]interface{}{
+				"url":   "https://example.com",
+				"files": []interface{}{"a.txt", float64(2)},
+				"repo":  map[string]interface{}{"revision": "main", "depth": float64(1)},
+				"count": "3",
+			},
+			[]string{"--tektasker-array-begin=files", "a.txt", "2", "--tektasker-array-end=files"},
+			[]string{
+				"PARAM_URL_VALUE=https://example.com",
+				"PARAM_REPO_REVISION_VALUE=main",
+				"PARAM_REPO_DEPTH_VALUE=1",
+				"PARAM_COUNT_VALUE=3",
+				"WORKSPACE_SOURCE_PATH=/tmp/source",
+			},
+		},
+		{
+			"Property left unset",
+			&ir.Step{Params: task.Params[2:3]},
+			map[string]interface{}{
+				"repo": map[string]interface{}{"revision": "main"},
+			},
+			nil,
+			[]string{
+				"PARAM_REPO_REVISION_VALUE=main",
+				"WORKSPACE_SOURCE_PATH=/tmp/source",
+			},
+		},
+		{
+			"Results and entrypoint of the step",
+			&ir.Step{Name: "build", Entrypoint: "build", Results: task.Results},
+			nil,
+			nil,
+			[]string{
+				"RESULT_DIGEST_PATH=/tmp/results/digest",
+				"WORKSPACE_SOURCE_PATH=/tmp/source",
+				"TEKTASKER_STEP=build",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			args, env := stepEnv(task, test.step, test.params, "/tmp/results", workspaces)
+			if !reflect.DeepEqual(args, test.args) {
+				t.Errorf("unwanted diff, got\n---\n%v\n---\nwanted\n---\n%v", args, test.args)
+			}
+
+			if !reflect.DeepEqual(env, test.env) {
+				t.Errorf("unwanted diff, got\n---\n%v\n---\nwanted\n---\n%v", env, test.env)
+			}
+		})
+	}
+}
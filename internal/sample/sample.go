@@ -0,0 +1,257 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package sample builds the manifests showing how to use a Task,
+// i.e. a TaskRun and a Pipeline consuming its results.
+package sample
+
+import (
+	"fmt"
+	"github.com/raskyld/go-tektasker/internal/ir"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Placeholder is the value given to a required param or property, e.g. `<who>`
+func Placeholder(name string) string {
+	return "<" + name + ">"
+}
+
+// TaskRun runs the Task with the default value of its params, the required ones
+// are given a placeholder and the workspaces are bound to an emptyDir
+func TaskRun(task *ir.Task, gv schema.GroupVersion) unstructured.Unstructured {
+	var taskRun unstructured.Unstructured
+	taskRun.SetGroupVersionKind(gv.WithKind("TaskRun"))
+	taskRun.SetGenerateName(task.Name + "-run-")
+
+	spec := map[string]interface{}{
+		"taskRef": map[string]interface{}{
+			"name": task.Name,
+		},
+	}
+
+	params := make([]interface{}, 0, len(task.Params))
+	for _, param := range task.Params {
+		params = append(params, map[string]interface{}{
+			"name":  param.Name,
+			"value": paramValue(param),
+		})
+	}
+
+	if len(params) > 0 {
+		spec["params"] = params
+	}
+
+	if workspaces := workspaceBindings(task); len(workspaces) > 0 {
+		spec["workspaces"] = workspaces
+	}
+
+	taskRun.Object["spec"] = spec
+	return taskRun
+}
+
+// Pipeline runs the Task then passes its string and array results to a downstream
+// task printing them. The required params of the Task are params of the Pipeline.
+func Pipeline(task *ir.Task, gv schema.GroupVersion) unstructured.Unstructured {
+	var pipeline unstructured.Unstructured
+	pipeline.SetGroupVersionKind(gv.WithKind("Pipeline"))
+	pipeline.SetName(task.Name + "-pipeline")
+
+	var pipelineParams, taskParams []interface{}
+	for _, param := range task.Params {
+		if param.Default != nil {
+			continue
+		}
+
+		pipelineParam := map[string]interface{}{
+			"name": param.Name,
+			"type": string(param.Type),
+		}
+
+		value := fmt.Sprintf("$(params.%s)", param.Name)
+		if param.Type != ir.TypeString {
+			value = fmt.Sprintf("$(params.%s[*])", param.Name)
+		}
+
+		if param.Type == ir.TypeObject {
+			pipelineParam["properties"] = properties(param.Properties)
+		}
+
+		pipelineParams = append(pipelineParams, pipelineParam)
+		taskParams = append(taskParams, map[string]interface{}{
+			"name":  param.Name,
+			"value": value,
+		})
+	}
+
+	pipelineTask := map[string]interface{}{
+		"name": task.Name,
+		"taskRef": map[string]interface{}{
+			"name": task.Name,
+		},
+	}
+
+	if len(taskParams) > 0 {
+		pipelineTask["params"] = taskParams
+	}
+
+	var pipelineWorkspaces, taskWorkspaces []interface{}
+	for _, workspace := range task.Workspaces {
+		pipelineWorkspace := map[string]interface{}{
+			"name": workspace.Name,
+		}
+
+		if workspace.Optional {
+			pipelineWorkspace["optional"] = true
+		}
+
+		pipelineWorkspaces = append(pipelineWorkspaces, pipelineWorkspace)
+		taskWorkspaces = append(taskWorkspaces, map[string]interface{}{
+			"name":      workspace.Name,
+			"workspace": workspace.Name,
+		})
+	}
+
+	if len(taskWorkspaces) > 0 {
+		pipelineTask["workspaces"] = taskWorkspaces
+	}
+
+	tasks := []interface{}{pipelineTask}
+	if downstream := downstreamTask(task); downstream != nil {
+		tasks = append(tasks, downstream)
+	}
+
+	spec := map[string]interface{}{
+		"tasks": tasks,
+	}
+
+	if len(pipelineParams) > 0 {
+		spec["params"] = pipelineParams
+	}
+
+	if len(pipelineWorkspaces) > 0 {
+		spec["workspaces"] = pipelineWorkspaces
+	}
+
+	pipeline.Object["spec"] = spec
+	return pipeline
+}
+
+// downstreamTask prints the string and array results of the Task,
+// it is nil when there is no such result
+func downstreamTask(task *ir.Task) map[string]interface{} {
+	var params, taskParams, args []interface{}
+	for _, result := range task.Results {
+		if result.Type == ir.TypeObject {
+			continue
+		}
+
+		value := fmt.Sprintf("$(tasks.%s.results.%s)", task.Name, result.Name)
+		arg := fmt.Sprintf("$(params.%s)", result.Name)
+		if result.Type == ir.TypeArray {
+			value = fmt.Sprintf("$(tasks.%s.results.%s[*])", task.Name, result.Name)
+			arg = fmt.Sprintf("$(params.%s[*])", result.Name)
+		}
+
+		params = append(params, map[string]interface{}{
+			"name": result.Name,
+			"type": string(result.Type),
+		})
+		taskParams = append(taskParams, map[string]interface{}{
+			"name":  result.Name,
+			"value": value,
+		})
+		args = append(args, arg)
+	}
+
+	if len(params) == 0 {
+		return nil
+	}
+
+	return map[string]interface{}{
+		"name":   "use-" + task.Name + "-results",
+		"params": taskParams,
+		"taskSpec": map[string]interface{}{
+			"params": params,
+			"steps": []interface{}{
+				map[string]interface{}{
+					"name":    "print",
+					"image":   "busybox",
+					"command": []interface{}{"echo"},
+					"args":    args,
+				},
+			},
+		},
+	}
+}
+
+// paramValue is the default value of the param or, when it is required,
+// the first value of its enum or a placeholder
+func paramValue(param *ir.Param) interface{} {
+	if param.Default != nil {
+		return param.Default
+	}
+
+	switch param.Type {
+	case ir.TypeArray:
+		return []interface{}{Placeholder(param.Name)}
+	case ir.TypeObject:
+		// an object without a default has a required property and Tekton
+		// expects a value for every property, even the optional ones
+		object := make(map[string]interface{}, len(param.Properties))
+		for _, property := range param.Properties {
+			object[property.Name] = Placeholder(property.Name)
+		}
+
+		return object
+	default:
+		// a placeholder would be rejected by Tekton
+		if len(param.Enum) > 0 {
+			return param.Enum[0]
+		}
+
+		return Placeholder(param.Name)
+	}
+}
+
+// workspaceBindings binds the workspaces which are not optional to an emptyDir
+func workspaceBindings(task *ir.Task) []interface{} {
+	var bindings []interface{}
+	for _, workspace := range task.Workspaces {
+		if workspace.Optional {
+			continue
+		}
+
+		bindings = append(bindings, map[string]interface{}{
+			"name":     workspace.Name,
+			"emptyDir": map[string]interface{}{},
+		})
+	}
+
+	return bindings
+}
+
+// properties declares the properties of an object param
+func properties(props []*ir.Property) map[string]interface{} {
+	rt := make(map[string]interface{}, len(props))
+	for _, property := range props {
+		rt[property.Name] = map[string]interface{}{
+			"type": string(property.Type),
+		}
+	}
+
+	return rt
+}
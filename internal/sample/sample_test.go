@@ -0,0 +1,152 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package sample
+
+import (
+	"github.com/raskyld/go-tektasker/internal/ir"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"reflect"
+	"testing"
+)
+
+var tektonV1 = schema.GroupVersion{Group: "tekton.dev", Version: "v1"}
+
+var helloTask = &ir.Task{
+	Name: "hello",
+	Params: []*ir.Param{
+		{Name: "who", Type: ir.TypeString, Default: "world"},
+		{Name: "files", Type: ir.TypeArray},
+		{Name: "conf", Type: ir.TypeObject, Properties: []*ir.Property{
+			{Name: "a", Type: ir.TypeString, Required: true},
+			{Name: "b", Type: ir.TypeString},
+		}},
+	},
+	Results: []*ir.Result{
+		{Name: "greeting", Type: ir.TypeString},
+		{Name: "meta", Type: ir.TypeObject},
+	},
+	Workspaces: []*ir.Workspace{
+		{Name: "src"},
+		{Name: "cache", Optional: true},
+	},
+}
+
+func TestTaskRun(t *testing.T) {
+	wanted := map[string]interface{}{
+		"apiVersion": "tekton.dev/v1",
+		"kind":       "TaskRun",
+		"metadata": map[string]interface{}{
+			"generateName": "hello-run-",
+		},
+		"spec": map[string]interface{}{
+			"taskRef": map[string]interface{}{"name": "hello"},
+			"params": []interface{}{
+				map[string]interface{}{"name": "who", "value": "world"},
+				map[string]interface{}{"name": "files", "value": []interface{}{"<files>"}},
+				map[string]interface{}{"name": "conf", "value": map[string]interface{}{"a": "<a>", "b": "<b>"}},
+			},
+			"workspaces": []interface{}{
+				map[string]interface{}{"name": "src", "emptyDir": map[string]interface{}{}},
+			},
+		},
+	}
+
+	got := TaskRun(helloTask, tektonV1).Object
+	if !reflect.DeepEqual(got, wanted) {
+		t.Errorf("unwanted diff, got\n---\n%v\n---\nwanted\n---\n%v", got, wanted)
+	}
+}
+
+func TestTaskRunEnum(t *testing.T) {
+	task := &ir.Task{
+		Name: "deploy",
+		Params: []*ir.Param{
+			{Name: "env", Type: ir.TypeString, Enum: []string{"staging", "prod"}},
+		},
+	}
+
+	wanted := []interface{}{
+		map[string]interface{}{"name": "env", "value": "staging"},
+	}
+
+	got, _, _ := unstructured.NestedSlice(TaskRun(task, tektonV1).Object, "spec", "params")
+	if !reflect.DeepEqual(got, wanted) {
+		t.Errorf("unwanted diff, got\n---\n%v\n---\nwanted\n---\n%v", got, wanted)
+	}
+}
+
+func TestPipeline(t *testing.T) {
+	wanted := map[string]interface{}{
+		"apiVersion": "tekton.dev/v1",
+		"kind":       "Pipeline",
+		"metadata": map[string]interface{}{
+			"name": "hello-pipeline",
+		},
+		"spec": map[string]interface{}{
+			"params": []interface{}{
+				map[string]interface{}{"name": "files", "type": "array"},
+				map[string]interface{}{"name": "conf", "type": "object", "properties": map[string]interface{}{
+					"a": map[string]interface{}{"type": "string"},
+					"b": map[string]interface{}{"type": "string"},
+				}},
+			},
+			"workspaces": []interface{}{
+				map[string]interface{}{"name": "src"},
+				map[string]interface{}{"name": "cache", "optional": true},
+			},
+			"tasks": []interface{}{
+				map[string]interface{}{
+					"name":    "hello",
+					"taskRef": map[string]interface{}{"name": "hello"},
+					"params": []interface{}{
+						map[string]interface{}{"name": "files", "value": "$(params.files[*])"},
+						map[string]interface{}{"name": "conf", "value": "$(params.conf[*])"},
+					},
+					"workspaces": []interface{}{
+						map[string]interface{}{"name": "src", "workspace": "src"},
+						map[string]interface{}{"name": "cache", "workspace": "cache"},
+					},
+				},
+				map[string]interface{}{
+					"name": "use-hello-results",
+					"params": []interface{}{
+						map[string]interface{}{"name": "greeting", "value": "$(tasks.hello.results.greeting)"},
+					},
+					"taskSpec": map[string]interface{}{
+						"params": []interface{}{
+							map[string]interface{}{"name": "greeting", "type": "string"},
+						},
+						"steps": []interface{}{
+							map[string]interface{}{
+								"name":    "print",
+								"image":   "busybox",
+								"command": []interface{}{"echo"},
+								"args":    []interface{}{"$(params.greeting)"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	got := Pipeline(helloTask, tektonV1).Object
+	if !reflect.DeepEqual(got, wanted) {
+		t.Errorf("unwanted diff, got\n---\n%v\n---\nwanted\n---\n%v", got, wanted)
+	}
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package verify checks the generated files on disk are up-to-date
+// by generating them in memory and comparing them.
+package verify
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/raskyld/go-tektasker/internal/diff"
+	"sigs.k8s.io/controller-tools/pkg/genall"
+	"sigs.k8s.io/controller-tools/pkg/loader"
+)
+
+// Recorder keeps in memory the files the generators would have written
+type Recorder struct {
+	files map[string]*bytes.Buffer
+
+	// paths keeps the order in which the files are generated
+	paths []string
+
+	// dirs are the directories the files are generated in by a ToDirectory rule along with
+	// the patterns of the names of the files it generates, the files matching them are
+	// expected to be recorded
+	dirs map[string][]string
+
+	// years match the lines of the header whose year may differ from the generated one
+	years []yearLine
+}
+
+// yearLine is a line of the header with a YEAR placeholder
+type yearLine struct {
+	onDisk    *regexp.Regexp
+	generated string
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		files: make(map[string]*bytes.Buffer),
+		dirs:  make(map[string][]string),
+	}
+}
+
+// AllowYears makes Diff ignore the year written in place of YEAR in the lines of the
+// header, so the files generated a previous year with the current year as default
+// are not stale
+func (r *Recorder) AllowYears(header, year string) {
+	for _, line := range strings.Split(header, "\n") {
+		before, after, found := strings.Cut(line, " YEAR")
+		if !found {
+			continue
+		}
+
+		r.years = append(r.years, yearLine{
+			onDisk:    regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(before) + ` [0-9]{4}` + regexp.QuoteMeta(after) + `$`),
+			generated: strings.ReplaceAll(line, " YEAR", " "+year),
+		})
+	}
+}
+
+// ToDirectory records the files genall.OutputToDirectory would have written,
+// generated are the patterns (see filepath.Match) of the names of the files the generator
+// owns, the files matching them which are no longer generated are reported by Diff
+func (r *Recorder) ToDirectory(dir string, generated ...string) genall.OutputRule {
+	return recordRule{
+		recorder: r,
+		resolve: func(_ *loader.Package, itemPath string) (string, error) {
+			path := filepath.Join(dir, itemPath)
+			r.dirs[filepath.Dir(path)] = generated
+			return path, nil
+		},
+	}
+}
+
+// ToPackage records the files genall.OutputArtifacts would have written next to the
+// sources of their package
+func (r *Recorder) ToPackage() genall.OutputRule {
+	return recordRule{
+		recorder: r,
+		resolve: func(pkg *loader.Package, itemPath string) (string, error) {
+			if pkg == nil || len(pkg.CompiledGoFiles) == 0 {
+				return "", fmt.Errorf("cannot output %s to a package with no path on disk", itemPath)
+			}
+
+			return filepath.Join(filepath.Dir(pkg.CompiledGoFiles[0]), itemPath), nil
+		},
+	}
+}
+
+// Diff writes the unified diff between the files on disk and the recorded ones,
+// it returns true when at least one file is stale.
+// The files left in the directories of a ToDirectory rule which are no longer generated,
+// e.g. the manifest of a removed Task, are stale too, as long as their name matches
+// the patterns of the rule so the files written by hand are left alone.
+func (r *Recorder) Diff(w io.Writer) (bool, error) {
+	stale := false
+	for _, path := range r.paths {
+		onDisk, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return false, err
+		}
+
+		for _, year := range r.years {
+			onDisk = year.onDisk.ReplaceAllLiteral(onDisk, []byte(year.generated))
+		}
+
+		name := filepath.ToSlash(path)
+		unified := diff.Unified("a/"+name, "b/"+name, string(onDisk), r.files[path].String())
+		if unified == "" {
+			continue
+		}
+
+		stale = true
+		_, err = io.WriteString(w, unified)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	leftovers, err := r.leftovers()
+	if err != nil {
+		return false, err
+	}
+
+	for _, path := range leftovers {
+		onDisk, err := os.ReadFile(path)
+		if err != nil {
+			return false, err
+		}
+
+		name := "a/" + filepath.ToSlash(path)
+		unified := diff.Unified(name, "/dev/null", string(onDisk), "")
+		if unified == "" {
+			// the diff of an empty file is empty
+			unified = fmt.Sprintf("--- %s\n+++ /dev/null\n", name)
+		}
+
+		stale = true
+		_, err = io.WriteString(w, unified)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return stale, nil
+}
+
+// leftovers lists the files on disk in the directories of the ToDirectory rules
+// which match their patterns but are not recorded, sorted by path
+func (r *Recorder) leftovers() ([]string, error) {
+	var paths []string
+	for dir, generated := range r.dirs {
+		if len(generated) == 0 {
+			continue
+		}
+
+		entries, err := os.ReadDir(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			path := filepath.Join(dir, entry.Name())
+			if _, recorded := r.files[path]; recorded || !entry.Type().IsRegular() {
+				continue
+			}
+
+			for _, pattern := range generated {
+				if matched, _ := filepath.Match(pattern, entry.Name()); matched {
+					paths = append(paths, path)
+					break
+				}
+			}
+		}
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
+
+func (r *Recorder) open(path string) io.WriteCloser {
+	buf, ok := r.files[path]
+	if !ok {
+		buf = &bytes.Buffer{}
+		r.files[path] = buf
+		r.paths = append(r.paths, path)
+	}
+
+	// like os.Create, opening a file again truncates it
+	buf.Reset()
+	return nopCloser{buf}
+}
+
+// recordRule is a genall.OutputRule writing to a Recorder
+type recordRule struct {
+	recorder *Recorder
+	resolve  func(pkg *loader.Package, itemPath string) (string, error)
+}
+
+func (o recordRule) Open(pkg *loader.Package, itemPath string) (io.WriteCloser, error) {
+	path, err := o.resolve(pkg, itemPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.recorder.open(path), nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (n nopCloser) Close() error {
+	return nil
+}
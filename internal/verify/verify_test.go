@@ -0,0 +1,184 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package verify
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRecorderDiff(t *testing.T) {
+	tests := []struct {
+		name      string
+		onDisk    map[string]string
+		generated map[string]string
+		patterns  []string
+		header    string
+		stale     bool
+		result    string
+	}{
+		{
+			"Up-to-date",
+			map[string]string{"base/a.yaml": "a\n"},
+			map[string]string{"base/a.yaml": "a\n"},
+			nil,
+			"",
+			false,
+			"",
+		},
+		{
+			"Stale file",
+			map[string]string{"base/a.yaml": "a\n"},
+			map[string]string{"base/a.yaml": "b\n"},
+			nil,
+			"",
+			true,
+			`--- a/DIR/base/a.yaml
++++ b/DIR/base/a.yaml
+@@ -1 +1 @@
+-a
++b
+`,
+		},
+		{
+			"Missing file",
+			nil,
+			map[string]string{"base/a.yaml": "a\n"},
+			nil,
+			"",
+			true,
+			`--- a/DIR/base/a.yaml
++++ b/DIR/base/a.yaml
+@@ -0,0 +1 @@
++a
+`,
+		},
+		{
+			"File no longer generated",
+			map[string]string{"base/a-task.yaml": "a\n", "base/removed-task.yaml": "removed\n", "other/b-task.yaml": "b\n"},
+			map[string]string{"base/a-task.yaml": "a\n"},
+			[]string{"*-task.yaml"},
+			"",
+			true,
+			`--- a/DIR/base/removed-task.yaml
++++ /dev/null
+@@ -1 +0,0 @@
+-removed
+`,
+		},
+		{
+			"Files written by hand",
+			map[string]string{"base/a-task.yaml": "a\n", "base/kustomization.yaml": "resources: []\n", "base/README.md": "# Tasks\n"},
+			map[string]string{"base/a-task.yaml": "a\n"},
+			[]string{"*-task.yaml"},
+			"",
+			false,
+			"",
+		},
+		{
+			"File of a rule without patterns",
+			map[string]string{"base/a-task.yaml": "a\n", "base/removed-task.yaml": "removed\n"},
+			map[string]string{"base/a-task.yaml": "a\n"},
+			nil,
+			"",
+			false,
+			"",
+		},
+		{
+			"Header of a previous year",
+			map[string]string{"a.go": "// Copyright 2023 Someone\n\npackage a\n"},
+			map[string]string{"a.go": "// Copyright 2024 Someone\n\npackage a\n"},
+			nil,
+			"// Copyright YEAR Someone\n",
+			false,
+			"",
+		},
+		{
+			"Header of a previous year in a stale file",
+			map[string]string{"a.go": "// Copyright 2023 Someone\n\npackage a\n"},
+			map[string]string{"a.go": "// Copyright 2024 Someone\n\npackage b\n"},
+			nil,
+			"// Copyright YEAR Someone\n",
+			true,
+			`--- a/DIR/a.go
++++ b/DIR/a.go
+@@ -1,3 +1,3 @@
+ // Copyright 2024 Someone
+ 
+-package a
++package b
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range test.onDisk {
+				path := filepath.Join(dir, name)
+				if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := os.WriteFile(path, []byte(content), 0666); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			recorder := NewRecorder()
+			if test.header != "" {
+				recorder.AllowYears(test.header, "2024")
+			}
+
+			rule := recorder.ToDirectory(dir, test.patterns...)
+			for name, content := range test.generated {
+				w, err := rule.Open(nil, name)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if _, err := io.WriteString(w, content); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := w.Close(); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			var buffer bytes.Buffer
+			stale, err := recorder.Diff(&buffer)
+			if err != nil {
+				t.Fatalf("Diff() error = %v", err)
+			}
+
+			if stale != test.stale {
+				t.Errorf("Diff() stale = %v, wanted %v", stale, test.stale)
+			}
+
+			result := strings.ReplaceAll(buffer.String(), filepath.ToSlash(dir), "DIR")
+			if !reflect.DeepEqual(result, test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", result, test.result)
+			}
+		})
+	}
+}
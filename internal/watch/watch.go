@@ -0,0 +1,293 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package watch re-runs a generation every time its sources change.
+package watch
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultInterval is how often the sources are polled
+const DefaultInterval = 500 * time.Millisecond
+
+// Generate runs a generation and returns the sources it was made from:
+// a directory stands for the Go files it holds and a directory followed by /...,
+// like the package patterns, stands for the packages it holds.
+// changed is nil for a full generation, otherwise it lists the directories of the
+// packages which changed since the previous generation and are the only ones to
+// generate again, the sources returned are then the ones of these packages.
+// The error of a generation is reported but does not stop the Watcher.
+type Generate func(changed []string) (sources []string, err error)
+
+// Watcher polls the sources of a generation and runs it again when they change.
+// NB(raskyld): polling avoids a dependency on a file notification library and
+// is cheap enough for the handful of packages a project holds.
+type Watcher struct {
+	Logger   *slog.Logger
+	Interval time.Duration
+}
+
+func New(logger *slog.Logger) *Watcher {
+	return &Watcher{
+		Logger:   logger.With("component", "watch"),
+		Interval: DefaultInterval,
+	}
+}
+
+// Run the generation then runs it again every time its sources change, until ctx is done.
+// Only the packages which changed are generated again unless a package is added or removed
+// or a source which is not a package changed.
+func (w *Watcher) Run(ctx context.Context, generate Generate) error {
+	var sources []string
+	var fingerprints map[string][]byte
+
+	ticker := time.NewTicker(w.Interval)
+	defer ticker.Stop()
+
+	full, changed := true, []string(nil)
+	for {
+		if full || len(changed) > 0 {
+			if full {
+				changed = nil
+			}
+
+			newSources, err := generate(changed)
+			if err != nil {
+				w.Logger.Error("generation failed", "error", err.Error())
+			} else if full {
+				w.Logger.Info("generation done, watching for changes")
+			} else {
+				w.Logger.Info("changed packages generated again, watching for changes", "packages", changed)
+			}
+
+			switch {
+			case !full:
+				sources = union(sources, newSources)
+			case len(newSources) > 0:
+				// keep watching the previous sources if the packages could not be loaded
+				sources = newSources
+			}
+
+			if len(sources) == 0 {
+				return errors.New("nothing to watch, the input packages could not be loaded")
+			}
+
+			fingerprints, err = Fingerprints(sources)
+			if err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		current, err := Fingerprints(sources)
+		if err != nil {
+			return err
+		}
+
+		full, changed = Changes(sources, fingerprints, current)
+	}
+}
+
+// Changes compares two fingerprints of the sources and returns the directories of the
+// packages which changed, full is true when a source which is not a package changed
+func Changes(sources []string, previous, current map[string][]byte) (full bool, changed []string) {
+	for _, source := range sources {
+		if bytes.Equal(previous[source], current[source]) {
+			continue
+		}
+
+		if isTree(source) {
+			return true, nil
+		}
+
+		info, err := os.Stat(source)
+		if err != nil || !info.IsDir() {
+			// a removed package is a removed source
+			return true, nil
+		}
+
+		changed = append(changed, source)
+	}
+
+	return false, changed
+}
+
+// Fingerprints hashes every source, the generated Go files and the tests of a
+// directory are left out as they can not change a generation
+func Fingerprints(sources []string) (map[string][]byte, error) {
+	fingerprints := make(map[string][]byte, len(sources))
+	for _, source := range sources {
+		var fingerprint []byte
+		var err error
+		if isTree(source) {
+			fingerprint, err = fingerprintTree(strings.TrimSuffix(source, "/..."))
+		} else {
+			fingerprint, err = Fingerprint([]string{source})
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		fingerprints[source] = fingerprint
+	}
+
+	return fingerprints, nil
+}
+
+// isTree tells whether the source stands for the packages of a directory
+func isTree(source string) bool {
+	return strings.HasSuffix(source, "/...")
+}
+
+// fingerprintTree hashes the list of directories holding Go files under root,
+// the directories ignored by the go command are left out
+func fingerprintTree(root string) ([]byte, error) {
+	dirs := make(map[string]struct{})
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		name := entry.Name()
+		if entry.IsDir() {
+			if path != root && (name == "testdata" || name == "vendor" ||
+				strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+
+			return nil
+		}
+
+		if strings.HasSuffix(name, ".go") && !strings.HasSuffix(name, "_test.go") {
+			dirs[filepath.Dir(path)] = struct{}{}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sorted := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sorted = append(sorted, dir)
+	}
+
+	sort.Strings(sorted)
+
+	hash := sha256.New()
+	for _, dir := range sorted {
+		_, _ = io.WriteString(hash, dir+"\x00")
+	}
+
+	return hash.Sum(nil), nil
+}
+
+// union appends the sources which are not already in the list
+func union(sources, added []string) []string {
+	seen := make(map[string]struct{}, len(sources))
+	for _, source := range sources {
+		seen[source] = struct{}{}
+	}
+
+	for _, source := range added {
+		if _, ok := seen[source]; !ok {
+			seen[source] = struct{}{}
+			sources = append(sources, source)
+		}
+	}
+
+	return sources
+}
+
+// Fingerprint hashes the content of the sources, the generated Go files
+// and the tests of a directory are left out as they can not change a generation
+func Fingerprint(sources []string) ([]byte, error) {
+	var files []string
+	for _, source := range sources {
+		info, err := os.Stat(source)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.IsDir() {
+			files = append(files, source)
+			continue
+		}
+
+		entries, err := os.ReadDir(source)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || !strings.HasSuffix(name, ".go") ||
+				strings.HasSuffix(name, "_test.go") || strings.HasPrefix(name, "zz_generated.") {
+				continue
+			}
+
+			files = append(files, filepath.Join(source, name))
+		}
+	}
+
+	sort.Strings(files)
+
+	hash := sha256.New()
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		_, _ = io.WriteString(hash, file+"\x00")
+		_, _ = hash.Write(content)
+		_, _ = io.WriteString(hash, "\x00")
+	}
+
+	return hash.Sum(nil), nil
+}
@@ -0,0 +1,226 @@
+/*
+Copyright 2023 Enzo Nocera <enzo@nocera.eu>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+   http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package watch
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeFile writes a file of the test directory, creating its parents
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0666); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestChanges(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(t *testing.T, dir string)
+		full   bool
+		result []string
+	}{
+		{
+			"Nothing changed",
+			func(t *testing.T, dir string) {},
+			false,
+			nil,
+		},
+		{
+			"Changed package",
+			func(t *testing.T, dir string) {
+				writeFile(t, dir, "pkg/a/a.go", "package a\n\nfunc A() {}\n")
+			},
+			false,
+			[]string{"pkg/a"},
+		},
+		{
+			"New file in a package",
+			func(t *testing.T, dir string) {
+				writeFile(t, dir, "pkg/b/other.go", "package b\n")
+			},
+			false,
+			[]string{"pkg/b"},
+		},
+		{
+			"Generated file and test",
+			func(t *testing.T, dir string) {
+				writeFile(t, dir, "pkg/a/zz_generated.tektasker.go", "package a\n")
+				writeFile(t, dir, "pkg/a/a_test.go", "package a\n")
+			},
+			false,
+			nil,
+		},
+		{
+			"New package",
+			func(t *testing.T, dir string) {
+				writeFile(t, dir, "pkg/c/c.go", "package c\n")
+			},
+			true,
+			nil,
+		},
+		{
+			"New package in testdata",
+			func(t *testing.T, dir string) {
+				writeFile(t, dir, "pkg/a/testdata/c/c.go", "package c\n")
+			},
+			false,
+			nil,
+		},
+		{
+			"Removed package",
+			func(t *testing.T, dir string) {
+				if err := os.RemoveAll(filepath.Join(dir, "pkg/b")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			true,
+			nil,
+		},
+		{
+			"Changed extra file",
+			func(t *testing.T, dir string) {
+				writeFile(t, dir, "header.txt", "// Copyright YEAR Someone\n")
+			},
+			true,
+			nil,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFile(t, dir, "pkg/a/a.go", "package a\n")
+			writeFile(t, dir, "pkg/b/b.go", "package b\n")
+			writeFile(t, dir, "header.txt", "// Copyright YEAR\n")
+
+			sources := []string{
+				filepath.Join(dir, "pkg/a"),
+				filepath.Join(dir, "pkg/b"),
+				filepath.Join(dir, "pkg") + "/...",
+				filepath.Join(dir, "header.txt"),
+			}
+
+			previous, err := Fingerprints(sources)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			test.change(t, dir)
+
+			current, err := Fingerprints(sources)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			full, changed := Changes(sources, previous, current)
+			if full != test.full {
+				t.Errorf("Changes() full = %v, wanted %v", full, test.full)
+			}
+
+			var result []string
+			for _, source := range changed {
+				rel, err := filepath.Rel(dir, source)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				result = append(result, filepath.ToSlash(rel))
+			}
+
+			if !reflect.DeepEqual(result, test.result) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", result, test.result)
+			}
+		})
+	}
+}
+
+func TestWatcherRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "pkg/a/a.go", "package a\n")
+	writeFile(t, dir, "pkg/b/b.go", "package b\n")
+
+	generations := make(chan []string)
+	generate := func(changed []string) ([]string, error) {
+		generations <- changed
+		if changed != nil {
+			return changed, nil
+		}
+
+		sources := []string{filepath.Join(dir, "pkg") + "/..."}
+		entries, err := os.ReadDir(filepath.Join(dir, "pkg"))
+		for _, entry := range entries {
+			sources = append(sources, filepath.Join(dir, "pkg", entry.Name()))
+		}
+
+		return sources, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watcher := New(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	watcher.Interval = 10 * time.Millisecond
+
+	done := make(chan error)
+	go func() {
+		done <- watcher.Run(ctx, generate)
+	}()
+
+	wait := func(wanted []string) {
+		t.Helper()
+
+		select {
+		case changed := <-generations:
+			if !reflect.DeepEqual(changed, wanted) {
+				t.Errorf("unwanted diff, got\n---\n%s\n---\nwanted\n---\n%s", changed, wanted)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("no generation of %s", wanted)
+		}
+	}
+
+	wait(nil)
+
+	writeFile(t, dir, "pkg/b/b.go", "package b\n\nfunc B() {}\n")
+	wait([]string{filepath.Join(dir, "pkg/b")})
+
+	writeFile(t, dir, "pkg/c/c.go", "package c\n")
+	wait(nil)
+
+	writeFile(t, dir, "pkg/c/c.go", "package c\n\nfunc C() {}\n")
+	wait([]string{filepath.Join(dir, "pkg/c")})
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Run() error = %v", err)
+	}
+}
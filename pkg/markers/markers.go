@@ -27,6 +27,17 @@ const (
 	MarkerResult    = "tektasker:result"
 	MarkerTask      = "tektasker:task"
 	MarkerWorkspace = "tektasker:workspace"
+	MarkerStep      = "tektasker:step"
+	MarkerSidecar   = "tektasker:sidecar"
+
+	MarkerStepAction = "tektasker:stepaction"
+
+	MarkerPipeline     = "tektasker:pipeline"
+	MarkerPipelineTask = "tektasker:pipelinetask"
+
+	MarkerImage           = "tektasker:image"
+	MarkerResources       = "tektasker:resources"
+	MarkerSecurityContext = "tektasker:securityContext"
 )
 
 type documentedMarker struct {
@@ -41,7 +52,9 @@ type hasHelp interface {
 // +controllertools:marker:generateHelp:category=task
 
 // Param marks structs as Task parameter which can then be used in your code
-// to take input from your users
+// to take input from your users.
+// Types defined from a builtin scalar (e.g. `int`, `bool`, `float64`) or from `time.Duration`
+// are parsed with strconv or time.ParseDuration, so `5m` or `true` need no JSON quoting.
 type Param struct {
 	// Name is the name of your parameter
 	Name string `marker:"name"`
@@ -54,7 +67,25 @@ type Param struct {
 	// by your user will need to be a valid JSON value that can be
 	// unmarshalled into your struct, that's why you need to put valid JSON tags
 	// in your structure fields.
+	// The fields become the properties of an object parameter, they must be strings
+	// and are required unless tagged with omitempty. Tekton only defaults a whole object,
+	// so the optional properties missing from the default are set to an empty string
+	// and an object whose properties are all optional defaults to empty strings.
 	Strict bool `marker:",optional"`
+
+	// Env overrides the name of the environment variable holding the value,
+	// by default, it is `PARAM_<NAME>_VALUE` where `<NAME>` is the upper-cased
+	// name with every character other than letters, digits and `_` replaced by `_`.
+	// Array parameters are passed through the args of the step and can not set it.
+	Env string `marker:"env,optional"`
+
+	// Enum restricts the values of a string parameter to the given set,
+	// it is enforced by Tekton and by the generated Read
+	Enum []string `marker:"enum,optional"`
+
+	// Pattern is a regular expression the value of a string parameter must match,
+	// e.g. `^v[0-9]+$`, it is enforced by the generated Read
+	Pattern string `marker:"pattern,optional"`
 }
 
 // +controllertools:marker:generateHelp:category=task
@@ -70,6 +101,21 @@ type Task struct {
 	// Version is a way to communicate the version of your task to
 	// your users
 	Version string `marker:"version"`
+
+	// DisplayName is the human-readable name of your Task shown by the Tekton Hub
+	DisplayName string `marker:"displayName,optional"`
+
+	// MinPipelinesVersion is the oldest Tekton Pipelines release your Task works with
+	MinPipelinesVersion string `marker:"minPipelinesVersion,optional"`
+
+	// Categories are the Tekton Hub categories of your Task (e.g. `Build Tools`)
+	Categories []string `marker:"categories,optional"`
+
+	// Tags are free-form keywords to find your Task in the Tekton Hub
+	Tags []string `marker:"tags,optional"`
+
+	// Platforms are the `os/arch` your Task can run on (e.g. `linux/amd64`)
+	Platforms []string `marker:"platforms,optional"`
 }
 
 // +controllertools:marker:generateHelp:category=task
@@ -79,11 +125,21 @@ type Task struct {
 type Result struct {
 	// Name is the name of the result
 	Name string `marker:"name"`
+
+	// Env overrides the name of the environment variable holding the path of the result,
+	// by default, it is `RESULT_<NAME>_PATH` where `<NAME>` is the upper-cased
+	// name with every character other than letters, digits and `_` replaced by `_`.
+	Env string `marker:"env,optional"`
+
+	// Strict turns a struct into an object result whose properties are taken
+	// from the JSON tags of its fields, which must be strings.
+	Strict bool `marker:",optional"`
 }
 
 // +controllertools:marker:generateHelp:category=task
 
-// Workspace asks a workspace for this task
+// Workspace asks a workspace for this task, its path is given to
+// the steps in `WORKSPACE_<NAME>_PATH`
 type Workspace struct {
 	// Name is the name of the workspace
 	Name string `marker:"name"`
@@ -105,6 +161,173 @@ type Workspace struct {
 	Optional bool `marker:"optional,optional"`
 }
 
+// +controllertools:marker:generateHelp:category=task
+
+// Step adds a step to your Task.
+// It can be used on your package or on a function of your package, in the latter
+// case the function is the entrypoint of the step and must have the `func() error` signature.
+// Steps run in the order they are declared. Without any step marker, your Task is
+// made of a single step running your main function.
+type Step struct {
+	// Name is the name of the step
+	Name string `marker:"name"`
+
+	// Entrypoint is the name of the `func() error` function to run in this step,
+	// it is implied when the marker is put on a function.
+	// Without any entrypoint, the step simply runs your main function.
+	Entrypoint string `marker:"entrypoint,optional"`
+
+	// Image overrides the container image of the step
+	Image string `marker:"image,optional"`
+
+	// Command overrides the command of the step
+	Command []string `marker:"command,optional"`
+
+	// Params is the subset of parameters exposed to the step, all of them by default.
+	// Use `{}` to expose none.
+	Params *[]string `marker:"params,optional"`
+
+	// Results is the subset of results the step can write, all of them by default.
+	// Use `{}` to allow none.
+	Results *[]string `marker:"results,optional"`
+}
+
+// +controllertools:marker:generateHelp:category=task
+
+// Sidecar runs a container next to the steps of your Task, for example,
+// a database or a registry your integration tests need
+type Sidecar struct {
+	// Name is the name of the sidecar
+	Name string `marker:"name"`
+
+	// Image is the container image of the sidecar
+	Image string `marker:"image"`
+
+	// Command overrides the entrypoint of the image
+	Command []string `marker:"command,optional"`
+
+	// ReadinessPort is a TCP port that must accept connections
+	// for the sidecar to be considered ready
+	ReadinessPort int `marker:"readinessPort,optional"`
+}
+
+// +controllertools:marker:generateHelp:category=task
+
+// Resources sets the compute resources of the steps of your Task.
+// Values are Kubernetes quantities (e.g. `100m` or `128Mi`).
+type Resources struct {
+	// CPURequest is the amount of CPU requested by each step
+	CPURequest string `marker:"cpuRequest,optional"`
+
+	// MemoryRequest is the amount of memory requested by each step
+	MemoryRequest string `marker:"memoryRequest,optional"`
+
+	// CPULimit is the maximum amount of CPU each step can use
+	CPULimit string `marker:"cpuLimit,optional"`
+
+	// MemoryLimit is the maximum amount of memory each step can use
+	MemoryLimit string `marker:"memoryLimit,optional"`
+}
+
+// +controllertools:marker:generateHelp:category=task
+
+// Image chooses how the container image running your package is built, it overrides
+// the image strategy of the configuration for this Task.
+// Every template can use `{{.TaskName}}`, `{{.Version}}`, `{{.PkgName}}`, `{{.ImportPath}}`
+// `{{.KoAppName}}` and `{{.KoEntrypoint}}`.
+type Image struct {
+	// Strategy is either `ko` (the default) to build the image with ko,
+	// `fixed` to use an image reference pinned by digest or
+	// `template` to compute the image reference from a template
+	Strategy string `marker:"strategy"`
+
+	// Reference is the image reference pinned by digest of the `fixed` strategy
+	// or the template of the image reference of the `template` strategy,
+	// e.g. `registry.example.com/tasks/{{.TaskName}}:{{.Version}}`
+	Reference string `marker:"reference,optional"`
+
+	// Command is the entrypoint of the image, without it the steps use the
+	// entrypoint of the image built by the `fixed` and `template` strategies
+	Command []string `marker:"command,optional"`
+}
+
+// +controllertools:marker:generateHelp:category=task
+
+// SecurityContext sets the security context of the steps of your Task
+type SecurityContext struct {
+	// RunAsNonRoot requires the steps to run as a non-root user
+	RunAsNonRoot *bool `marker:"runAsNonRoot,optional"`
+
+	// RunAsUser is the UID the steps run as
+	RunAsUser *int `marker:"runAsUser,optional"`
+
+	// ReadOnlyRootFilesystem mounts the root filesystem of the steps as read-only
+	ReadOnlyRootFilesystem *bool `marker:"readOnlyRootFilesystem,optional"`
+
+	// AddCapabilities are the Linux capabilities to add (e.g. `NET_ADMIN`)
+	AddCapabilities []string `marker:"addCapabilities,optional"`
+
+	// DropCapabilities are the Linux capabilities to drop (e.g. `ALL`)
+	DropCapabilities []string `marker:"dropCapabilities,optional"`
+}
+
+// +controllertools:marker:generateHelp:category=stepaction
+
+// StepAction marks your package as a StepAction, a single step that other Tasks can
+// reference. Like a Task, your package needs to be executable and its params and results
+// are declared with the param and result markers. As the Task referencing it owns the
+// workspaces, sidecars and compute resources, their markers can not be used.
+type StepAction struct {
+	// Name is the name of your StepAction.
+	// It will be used as the name of your StepAction manifest.
+	Name string `marker:"name"`
+
+	// Version is a way to communicate the version of your step action to
+	// your users
+	Version string `marker:"version"`
+}
+
+// +controllertools:marker:generateHelp:category=pipeline
+
+// Pipeline marks your package as a Pipeline made of the tasks declared with
+// the pipelinetask marker. The params and workspaces of the Pipeline are declared
+// the same way as the ones of a Task, with the param and workspace markers.
+type Pipeline struct {
+	// Name is the name of your Pipeline.
+	// It will be used as the name of your Pipeline manifest.
+	Name string `marker:"name"`
+
+	// Version is a way to communicate the version of your pipeline to
+	// your users
+	Version string `marker:"version"`
+}
+
+// +controllertools:marker:generateHelp:category=pipeline
+
+// PipelineTask adds a task to your Pipeline, the task is the Task of another
+// package generated along with your Pipeline and every reference to its params,
+// results and workspaces is checked against it
+type PipelineTask struct {
+	// Name is the name of the task in the Pipeline
+	Name string `marker:"name"`
+
+	// Task is the import path of the package holding the Task
+	Task string `marker:"task"`
+
+	// RunAfter are the names of the tasks of the Pipeline which must run before this one
+	RunAfter []string `marker:"runAfter,optional"`
+
+	// Params gives a value to the params of the Task, the value can reference the params of
+	// the Pipeline and the results of its tasks, e.g.
+	// `{who: "$(params.who)", files: "$(tasks.list.results.files[*])"}`.
+	// The params of the Task without default value are required.
+	Params map[string]string `marker:"params,optional"`
+
+	// Workspaces binds the workspaces of the Task to the workspaces of the Pipeline, e.g.
+	// `{source: shared}`. The workspaces of the Task which are not optional are required.
+	Workspaces map[string]string `marker:"workspaces,optional"`
+}
+
 func define(name string, targetType markers.TargetType, help hasHelp) {
 	markersDef = append(markersDef, documentedMarker{
 		markers.Must(markers.MakeDefinition(name, targetType, help)),
@@ -117,6 +340,14 @@ func init() {
 	define(MarkerResult, markers.DescribesType, Result{})
 	define(MarkerTask, markers.DescribesPackage, Task{})
 	define(MarkerWorkspace, markers.DescribesPackage, Workspace{})
+	define(MarkerStep, markers.DescribesPackage, Step{})
+	define(MarkerSidecar, markers.DescribesPackage, Sidecar{})
+	define(MarkerImage, markers.DescribesPackage, Image{})
+	define(MarkerResources, markers.DescribesPackage, Resources{})
+	define(MarkerSecurityContext, markers.DescribesPackage, SecurityContext{})
+	define(MarkerStepAction, markers.DescribesPackage, StepAction{})
+	define(MarkerPipeline, markers.DescribesPackage, Pipeline{})
+	define(MarkerPipelineTask, markers.DescribesPackage, PipelineTask{})
 }
 
 // Register all the markers in passed markers.Registry
@@ -24,11 +24,35 @@ import (
 	"sigs.k8s.io/controller-tools/pkg/markers"
 )
 
+func (Image) Help() *markers.DefinitionHelp {
+	return &markers.DefinitionHelp{
+		Category: "task",
+		DetailedHelp: markers.DetailedHelp{
+			Summary: "chooses how the container image running your package is built, it overrides the image strategy of the configuration for this Task. Every template can use `{{.TaskName}}`, `{{.Version}}`, `{{.PkgName}}`, `{{.ImportPath}}` `{{.KoAppName}}` and `{{.KoEntrypoint}}`.",
+			Details: "",
+		},
+		FieldHelp: map[string]markers.DetailedHelp{
+			"Strategy": {
+				Summary: "is either `ko` (the default) to build the image with ko, `fixed` to use an image reference pinned by digest or `template` to compute the image reference from a template",
+				Details: "",
+			},
+			"Reference": {
+				Summary: "is the image reference pinned by digest of the `fixed` strategy or the template of the image reference of the `template` strategy, e.g. `registry.example.com/tasks/{{.TaskName}}:{{.Version}}`",
+				Details: "",
+			},
+			"Command": {
+				Summary: "is the entrypoint of the image, without it the steps use the entrypoint of the image built by the `fixed` and `template` strategies",
+				Details: "",
+			},
+		},
+	}
+}
+
 func (Param) Help() *markers.DefinitionHelp {
 	return &markers.DefinitionHelp{
 		Category: "task",
 		DetailedHelp: markers.DetailedHelp{
-			Summary: "marks structs as Task parameter which can then be used in your code to take input from your users",
+			Summary: "marks structs as Task parameter which can then be used in your code to take input from your users. Types defined from a builtin scalar (e.g. `int`, `bool`, `float64`) or from `time.Duration` are parsed with strconv or time.ParseDuration, so `5m` or `true` need no JSON quoting.",
 			Details: "",
 		},
 		FieldHelp: map[string]markers.DetailedHelp{
@@ -41,7 +65,99 @@ func (Param) Help() *markers.DefinitionHelp {
 				Details: "",
 			},
 			"Strict": {
-				Summary: "means you expect the parameter to strictly respect the format of your struct. For this to be possible, the value passed to this parameter by your user will need to be a valid JSON value that can be unmarshalled into your struct, that's why you need to put valid JSON tags in your structure fields.",
+				Summary: "means you expect the parameter to strictly respect the format of your struct. For this to be possible, the value passed to this parameter by your user will need to be a valid JSON value that can be unmarshalled into your struct, that's why you need to put valid JSON tags in your structure fields. The fields become the properties of an object parameter, they must be strings and are required unless tagged with omitempty. Tekton only defaults a whole object, so the optional properties missing from the default are set to an empty string and an object whose properties are all optional defaults to empty strings.",
+				Details: "",
+			},
+			"Env": {
+				Summary: "overrides the name of the environment variable holding the value, by default, it is `PARAM_<NAME>_VALUE` where `<NAME>` is the upper-cased name with every character other than letters, digits and `_` replaced by `_`. Array parameters are passed through the args of the step and can not set it.",
+				Details: "",
+			},
+			"Enum": {
+				Summary: "restricts the values of a string parameter to the given set, it is enforced by Tekton and by the generated Read",
+				Details: "",
+			},
+			"Pattern": {
+				Summary: "is a regular expression the value of a string parameter must match, e.g. `^v[0-9]+$`, it is enforced by the generated Read",
+				Details: "",
+			},
+		},
+	}
+}
+
+func (Pipeline) Help() *markers.DefinitionHelp {
+	return &markers.DefinitionHelp{
+		Category: "pipeline",
+		DetailedHelp: markers.DetailedHelp{
+			Summary: "marks your package as a Pipeline made of the tasks declared with the pipelinetask marker. The params and workspaces of the Pipeline are declared the same way as the ones of a Task, with the param and workspace markers.",
+			Details: "",
+		},
+		FieldHelp: map[string]markers.DetailedHelp{
+			"Name": {
+				Summary: "is the name of your Pipeline. It will be used as the name of your Pipeline manifest.",
+				Details: "",
+			},
+			"Version": {
+				Summary: "is a way to communicate the version of your pipeline to your users",
+				Details: "",
+			},
+		},
+	}
+}
+
+func (PipelineTask) Help() *markers.DefinitionHelp {
+	return &markers.DefinitionHelp{
+		Category: "pipeline",
+		DetailedHelp: markers.DetailedHelp{
+			Summary: "adds a task to your Pipeline, the task is the Task of another package generated along with your Pipeline and every reference to its params, results and workspaces is checked against it",
+			Details: "",
+		},
+		FieldHelp: map[string]markers.DetailedHelp{
+			"Name": {
+				Summary: "is the name of the task in the Pipeline",
+				Details: "",
+			},
+			"Task": {
+				Summary: "is the import path of the package holding the Task",
+				Details: "",
+			},
+			"RunAfter": {
+				Summary: "are the names of the tasks of the Pipeline which must run before this one",
+				Details: "",
+			},
+			"Params": {
+				Summary: "gives a value to the params of the Task, the value can reference the params of the Pipeline and the results of its tasks, e.g. `{who: \"$(params.who)\", files: \"$(tasks.list.results.files[*])\"}`. The params of the Task without default value are required.",
+				Details: "",
+			},
+			"Workspaces": {
+				Summary: "binds the workspaces of the Task to the workspaces of the Pipeline, e.g. `{source: shared}`. The workspaces of the Task which are not optional are required.",
+				Details: "",
+			},
+		},
+	}
+}
+
+func (Resources) Help() *markers.DefinitionHelp {
+	return &markers.DefinitionHelp{
+		Category: "task",
+		DetailedHelp: markers.DetailedHelp{
+			Summary: "sets the compute resources of the steps of your Task. Values are Kubernetes quantities (e.g. `100m` or `128Mi`).",
+			Details: "",
+		},
+		FieldHelp: map[string]markers.DetailedHelp{
+			"CPURequest": {
+				Summary: "is the amount of CPU requested by each step",
+				Details: "",
+			},
+			"MemoryRequest": {
+				Summary: "is the amount of memory requested by each step",
+				Details: "",
+			},
+			"CPULimit": {
+				Summary: "is the maximum amount of CPU each step can use",
+				Details: "",
+			},
+			"MemoryLimit": {
+				Summary: "is the maximum amount of memory each step can use",
 				Details: "",
 			},
 		},
@@ -60,6 +176,130 @@ func (Result) Help() *markers.DefinitionHelp {
 				Summary: "is the name of the result",
 				Details: "",
 			},
+			"Env": {
+				Summary: "overrides the name of the environment variable holding the path of the result, by default, it is `RESULT_<NAME>_PATH` where `<NAME>` is the upper-cased name with every character other than letters, digits and `_` replaced by `_`.",
+				Details: "",
+			},
+			"Strict": {
+				Summary: "turns a struct into an object result whose properties are taken from the JSON tags of its fields, which must be strings.",
+				Details: "",
+			},
+		},
+	}
+}
+
+func (SecurityContext) Help() *markers.DefinitionHelp {
+	return &markers.DefinitionHelp{
+		Category: "task",
+		DetailedHelp: markers.DetailedHelp{
+			Summary: "sets the security context of the steps of your Task",
+			Details: "",
+		},
+		FieldHelp: map[string]markers.DetailedHelp{
+			"RunAsNonRoot": {
+				Summary: "requires the steps to run as a non-root user",
+				Details: "",
+			},
+			"RunAsUser": {
+				Summary: "is the UID the steps run as",
+				Details: "",
+			},
+			"ReadOnlyRootFilesystem": {
+				Summary: "mounts the root filesystem of the steps as read-only",
+				Details: "",
+			},
+			"AddCapabilities": {
+				Summary: "are the Linux capabilities to add (e.g. `NET_ADMIN`)",
+				Details: "",
+			},
+			"DropCapabilities": {
+				Summary: "are the Linux capabilities to drop (e.g. `ALL`)",
+				Details: "",
+			},
+		},
+	}
+}
+
+func (Sidecar) Help() *markers.DefinitionHelp {
+	return &markers.DefinitionHelp{
+		Category: "task",
+		DetailedHelp: markers.DetailedHelp{
+			Summary: "runs a container next to the steps of your Task, for example, a database or a registry your integration tests need",
+			Details: "",
+		},
+		FieldHelp: map[string]markers.DetailedHelp{
+			"Name": {
+				Summary: "is the name of the sidecar",
+				Details: "",
+			},
+			"Image": {
+				Summary: "is the container image of the sidecar",
+				Details: "",
+			},
+			"Command": {
+				Summary: "overrides the entrypoint of the image",
+				Details: "",
+			},
+			"ReadinessPort": {
+				Summary: "is a TCP port that must accept connections for the sidecar to be considered ready",
+				Details: "",
+			},
+		},
+	}
+}
+
+func (Step) Help() *markers.DefinitionHelp {
+	return &markers.DefinitionHelp{
+		Category: "task",
+		DetailedHelp: markers.DetailedHelp{
+			Summary: "adds a step to your Task. It can be used on your package or on a function of your package, in the latter case the function is the entrypoint of the step and must have the `func() error` signature. Steps run in the order they are declared. Without any step marker, your Task is made of a single step running your main function.",
+			Details: "",
+		},
+		FieldHelp: map[string]markers.DetailedHelp{
+			"Name": {
+				Summary: "is the name of the step",
+				Details: "",
+			},
+			"Entrypoint": {
+				Summary: "is the name of the `func() error` function to run in this step, it is implied when the marker is put on a function. Without any entrypoint, the step simply runs your main function.",
+				Details: "",
+			},
+			"Image": {
+				Summary: "overrides the container image of the step",
+				Details: "",
+			},
+			"Command": {
+				Summary: "overrides the command of the step",
+				Details: "",
+			},
+			"Params": {
+				Summary: "is the subset of parameters exposed to the step, all of them by default. Use `{}` to expose none.",
+				Details: "",
+			},
+			"Results": {
+				Summary: "is the subset of results the step can write, all of them by default. Use `{}` to allow none.",
+				Details: "",
+			},
+		},
+	}
+}
+
+func (StepAction) Help() *markers.DefinitionHelp {
+	return &markers.DefinitionHelp{
+		Category: "stepaction",
+		DetailedHelp: markers.DetailedHelp{
+			Summary: "marks your package as a StepAction, a single step that other Tasks can reference. Like a Task, your package needs to be executable and its params and results are declared with the param and result markers. As the Task referencing it owns the workspaces, sidecars and compute resources, their markers can not be used.",
+			Details: "",
+		},
+		FieldHelp: map[string]markers.DetailedHelp{
+			"Name": {
+				Summary: "is the name of your StepAction. It will be used as the name of your StepAction manifest.",
+				Details: "",
+			},
+			"Version": {
+				Summary: "is a way to communicate the version of your step action to your users",
+				Details: "",
+			},
 		},
 	}
 }
@@ -80,6 +320,26 @@ func (Task) Help() *markers.DefinitionHelp {
 				Summary: "is a way to communicate the version of your task to your users",
 				Details: "",
 			},
+			"DisplayName": {
+				Summary: "is the human-readable name of your Task shown by the Tekton Hub",
+				Details: "",
+			},
+			"MinPipelinesVersion": {
+				Summary: "is the oldest Tekton Pipelines release your Task works with",
+				Details: "",
+			},
+			"Categories": {
+				Summary: "are the Tekton Hub categories of your Task (e.g. `Build Tools`)",
+				Details: "",
+			},
+			"Tags": {
+				Summary: "are free-form keywords to find your Task in the Tekton Hub",
+				Details: "",
+			},
+			"Platforms": {
+				Summary: "are the `os/arch` your Task can run on (e.g. `linux/amd64`)",
+				Details: "",
+			},
 		},
 	}
 }
@@ -88,7 +348,7 @@ func (Workspace) Help() *markers.DefinitionHelp {
 	return &markers.DefinitionHelp{
 		Category: "task",
 		DetailedHelp: markers.DetailedHelp{
-			Summary: "asks a workspace for this task",
+			Summary: "asks a workspace for this task, its path is given to the steps in `WORKSPACE_<NAME>_PATH`",
 			Details: "",
 		},
 		FieldHelp: map[string]markers.DetailedHelp{